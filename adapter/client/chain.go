@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// chainIDBackend is implemented by backends that can report the chain they
+// are connected to.
+type chainIDBackend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// ChainID returns the chain ID reported by eth_chainId, falling back to
+// net_version for nodes that do not implement it.
+func (backend *rpcBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	var chainID hexutil.Big
+	if err := backend.rpc.CallContext(ctx, &chainID, "eth_chainId"); err == nil {
+		return chainID.ToInt(), nil
+	}
+	return backend.NetworkID(ctx)
+}
+
+// ChainID returns the chain of the pool, which every endpoint that is used
+// has been verified to be on. It returns ErrNoHealthyEndpoint if no endpoint
+// has reported its chain yet.
+func (pool *Pool) ChainID(ctx context.Context) (*big.Int, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for _, ep := range pool.endpoints {
+		if ep.verified {
+			return new(big.Int).Set(pool.chainID), nil
+		}
+	}
+	return nil, ErrNoHealthyEndpoint
+}
+
+// verifyChain returns a ChainMismatchError if the backend is not on the chain
+// of the network. Networks without a chain ID are not checked.
+func verifyChain(ctx context.Context, backend Backend, network Network) error {
+	if network.ChainID == 0 {
+		return nil
+	}
+	chainBackend, ok := backend.(chainIDBackend)
+	if !ok {
+		return nil
+	}
+	have, err := chainBackend.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	want := chainID(network)
+	if have.Cmp(want) != 0 {
+		return ChainMismatchError{Network: network.Name, Want: want, Have: have}
+	}
+	return nil
+}
+
+func chainID(network Network) *big.Int {
+	if network.ChainID == 0 {
+		return nil
+	}
+	return new(big.Int).SetUint64(network.ChainID)
+}
@@ -2,7 +2,7 @@ package client
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"math/big"
 	"time"
 
@@ -11,87 +11,53 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// Backend is the subset of the Ethereum JSON-RPC API used by the SDK. It is
+// implemented by RPC connections, by a Pool of them and by the simulated local
+// chain.
+type Backend interface {
+	bind.ContractBackend
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+
+	// ReceiptBlock returns the number and hash of the block that includes a
+	// transaction, or ethereum.NotFound if it has not been mined.
+	ReceiptBlock(ctx context.Context, txHash common.Hash) (uint64, common.Hash, error)
+}
+
 type Client interface {
 	Network() string
-	Client() *ethclient.Client
+	ChainID() *big.Int
+	Client() Backend
 	OrderbookAddress() common.Address
 	DarknodeRegistryAddress() common.Address
 	RenExSettlementAddress() common.Address
 	RenExBalancesAddress() common.Address
 	RenExTokensAddress() common.Address
 	WaitTillMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error)
-	Transfer(to common.Address, from *bind.TransactOpts, value *big.Int) error
-}
-
-type Network struct {
-	URL                     string `json:"url"`
-	Chain                   string `json:"chain"`
-	OrderbookAddress        string `json:"orderbook"`
-	DarknodeRegistryAddress string `json:"darknodeRegistry"`
-	RenExBalancesAddress    string `json:"renExBalances"`
-	RenExTokensAddress      string `json:"renExTokens"`
-	RenExSettlementAddress  string `json:"renExSettlement"`
-	RenExAtomicInfoAddress  string `json:"renExAtomicInfo"`
+	WaitConfirmed(ctx context.Context, tx *types.Transaction, confirmations uint64) (Confirmation, error)
+	Confirmations() uint64
+	Replace(original, replacement common.Hash, cancel bool)
+	Transfer(ctx context.Context, to common.Address, from *bind.TransactOpts, value *big.Int) error
+	Close() error
 }
 
 type client struct {
 	network          string
-	client           *ethclient.Client
+	chain            string
+	client           Backend
 	orderbook        common.Address
 	darknodeRegistry common.Address
 	renExBalances    common.Address
 	renExTokens      common.Address
 	renExSettlement  common.Address
-}
-
-func GetNetwork(network string) (Network, error) {
-	switch network {
-	case "mainnet":
-		return Network{
-			URL:   "https://mainnet.infura.io",
-			Chain: "mainnet",
-			DarknodeRegistryAddress: "0x3799006a87fde3ccfc7666b3e6553b03ed341c2f",
-			OrderbookAddress:        "0x6b8bb175c092de7d81860b18db360b734a2598e0",
-			RenExBalancesAddress:    "0x9636f9ac371ca0965b7c2b4ad13c4cc64d0ff2dc",
-			RenExSettlementAddress:  "0x908262de0366e42d029b0518d5276762c92b21e1",
-			RenExTokensAddress:      "0x7cade4fbc8761817bb62a080733d1b6cad744ec4",
-		}, nil
-	case "testnet":
-		return Network{
-			URL:   "https://kovan.infura.io",
-			Chain: "kovan",
-			DarknodeRegistryAddress: "0xf7daA0Baf257547A6Ad3CE7FFF71D55cb7426F76",
-			OrderbookAddress:        "0xA53Da4093c682a4259DE38302341BFEf7e9f7a4f",
-			RenExBalancesAddress:    "0x97073d0d654ebb71dd9efd1dfa777c73f56d4021",
-			RenExSettlementAddress:  "0x68FE2088A321A42DE11Aba93D32C81C9f20b1Abe",
-			RenExTokensAddress:      "0xedFF6E7C072fA0018720734F6d5a4f4DC30f9869",
-		}, nil
-	case "falcon":
-		return Network{
-			URL:   "https://kovan.infura.io",
-			Chain: "kovan",
-			DarknodeRegistryAddress: "0xDaA8C30AF85070506F641E456aFDB84d4bA972Bd",
-			OrderbookAddress:        "0x592d16f8C5FA8f1E074ab3C2cd1ACD087ADcdc0B",
-			RenExBalancesAddress:    "0xb3E632943fA995FC75692e46b62383BE49cDdbc4",
-			RenExSettlementAddress:  "0xBE936cb23DD9a84E4D9358810f7F275e93CCD770",
-			RenExTokensAddress:      "0x9a898c8148131eF189B1c8575692376403780325",
-		}, nil
-	case "nightly":
-		return Network{
-			URL:   "https://kovan.infura.io",
-			Chain: "kovan",
-			DarknodeRegistryAddress: "0x8a31d477267A5af1bc5142904ef0AfA31D326E03",
-			OrderbookAddress:        "0x376127aDc18260fc238eBFB6626b2F4B59eC9b66",
-			RenExBalancesAddress:    "0xa95dE870dDFB6188519D5CC63CEd5E0FBac1aa8E",
-			RenExSettlementAddress:  "0x5f25233ca99104D31612D4fB937B090d5A2EbB75",
-			RenExTokensAddress:      "0x160ECA47935be4139eC5B94D99B678d6f7e18f95",
-		}, nil
-	default:
-		return Network{}, fmt.Errorf("Unknown Network")
-	}
+	confirmations    uint64
+	chainID          *big.Int
+	replacements     *replacements
 }
 
 // NewClient creates a new ethereum client.
@@ -100,23 +66,67 @@ func NewClient(net string) (Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewClientFromNetwork(network)
+}
+
+// NewClientFromNetwork creates a new ethereum client connected to the RPC
+// endpoint and contracts of a network definition.
+// When the network has fallback URLs, calls are spread over a health checked
+// Pool of all its endpoints. Missing contract addresses are discovered from
+// the RenExSettlement or Orderbook address, see DiscoverNetwork, and the
+// versions of the contracts are checked, see VerifyNetwork.
+// A ChainMismatchError is returned if the endpoints are not on the chain of
+// the network.
+func NewClientFromNetwork(network Network) (Client, error) {
+	var backend Backend
+	if len(network.FallbackURLs) > 0 {
+		pool, err := NewPool(append([]string{network.URL}, network.FallbackURLs...), PoolConfig{ChainID: chainID(network)})
+		if err != nil {
+			return nil, err
+		}
+		backend = pool
+	} else {
+		conn, err := dial(network.URL)
+		if err != nil {
+			return nil, err
+		}
+		backend = conn
+	}
 
-	ethclient, err := ethclient.Dial(network.URL)
+	network, err := checkNetwork(backend, network)
 	if err != nil {
+		closeBackend(backend)
 		return nil, err
 	}
 
 	return &client{
-		client:           ethclient,
-		network:          network.Chain,
+		client:           backend,
+		network:          network.Name,
+		chain:            network.Chain,
 		orderbook:        common.HexToAddress(network.OrderbookAddress),
 		darknodeRegistry: common.HexToAddress(network.DarknodeRegistryAddress),
 		renExBalances:    common.HexToAddress(network.RenExBalancesAddress),
 		renExTokens:      common.HexToAddress(network.RenExTokensAddress),
 		renExSettlement:  common.HexToAddress(network.RenExSettlementAddress),
+		confirmations:    network.Confirmations,
+		chainID:          chainID(network),
+		replacements:     newReplacements(),
 	}, nil
 }
 
+// checkNetwork checks that the backend is on the chain of the network and
+// that the contracts of the network are supported, and fills in the missing
+// contract addresses.
+func checkNetwork(backend Backend, network Network) (Network, error) {
+	if err := verifyChain(context.Background(), backend, network); err != nil {
+		return Network{}, err
+	}
+	if network.complete() {
+		return network, VerifyNetwork(context.Background(), backend, network)
+	}
+	return DiscoverNetwork(context.Background(), backend, network)
+}
+
 // NewAccount creates a new account and funds it with ether
 func (b *client) NewAccount(value int64, from *bind.TransactOpts) (common.Address, *bind.TransactOpts, error) {
 	account, err := crypto.GenerateKey()
@@ -127,11 +137,11 @@ func (b *client) NewAccount(value int64, from *bind.TransactOpts) (common.Addres
 	accountAddress := crypto.PubkeyToAddress(account.PublicKey)
 	accountAuth := bind.NewKeyedTransactor(account)
 
-	return accountAddress, accountAuth, b.Transfer(accountAddress, from, big.NewInt(value))
+	return accountAddress, accountAuth, b.Transfer(context.Background(), accountAddress, from, big.NewInt(value))
 }
 
 // Transfer is a helper function for sending ETH to an address
-func (b *client) Transfer(to common.Address, from *bind.TransactOpts, value *big.Int) error {
+func (b *client) Transfer(ctx context.Context, to common.Address, from *bind.TransactOpts, value *big.Int) error {
 	transactor := &bind.TransactOpts{
 		From:     from.From,
 		Nonce:    from.Nonce,
@@ -139,7 +149,7 @@ func (b *client) Transfer(to common.Address, from *bind.TransactOpts, value *big
 		Value:    value,
 		GasPrice: from.GasPrice,
 		GasLimit: 30000,
-		Context:  from.Context,
+		Context:  ctx,
 	}
 
 	// Why is there no ethclient.Transfer?
@@ -148,35 +158,23 @@ func (b *client) Transfer(to common.Address, from *bind.TransactOpts, value *big
 	if err != nil {
 		return err
 	}
-	_, err = b.WaitTillMined(context.Background(), tx)
+	_, err = b.WaitTillMined(ctx, tx)
 	return err
 }
 
-// WaitTillMined waits for tx to be mined on the blockchain.
-// It stops waiting when the context is canceled.
-//
-// TODO: THIS DOES NOT WORK WITH PARITY, WHICH SENDS A TRANSACTION RECEIPT UPON
-// RECEIVING A TX, NOT AFTER IT'S MINED
+// WaitTillMined waits for tx to be mined into a canonical block on the
+// blockchain, see WaitConfirmed. It stops waiting when the context is canceled.
 func (b *client) WaitTillMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
-	switch b.network {
+	switch b.chain {
 	case "ganache":
 		time.Sleep(100 * time.Millisecond)
 		return nil, nil
 	default:
-		if b.client == nil {
-			return nil, fmt.Errorf("Nil Client")
-		}
-		if tx == nil {
-			return nil, fmt.Errorf("Nil Tx")
-		}
-		reciept, err := bind.WaitMined(ctx, b.client, tx)
+		conf, err := b.WaitConfirmed(ctx, tx, 1)
 		if err != nil {
 			return nil, err
 		}
-		if reciept.Status != 1 {
-			return nil, fmt.Errorf("Transaction reverted")
-		}
-		return reciept, nil
+		return conf.Receipt, nil
 	}
 }
 
@@ -186,7 +184,7 @@ func (b *client) WaitTillMined(ctx context.Context, tx *types.Transaction) (*typ
 // TODO: THIS DOES NOT WORK WITH PARITY, WHICH SENDS A TRANSACTION RECEIPT UPON
 // RECEIVING A TX, NOT AFTER IT'S MINED
 func (b *client) PatchedWaitDeployed(ctx context.Context, tx *types.Transaction) (common.Address, error) {
-	switch b.network {
+	switch b.chain {
 	case "ganache":
 		time.Sleep(100 * time.Millisecond)
 		return common.Address{}, nil
@@ -214,10 +212,34 @@ func (client *client) RenExTokensAddress() common.Address {
 	return client.renExTokens
 }
 
+// Network returns the name of the network of the client, which can be looked
+// up with GetNetwork.
 func (client *client) Network() string {
 	return client.network
 }
 
-func (client *client) Client() *ethclient.Client {
+// ChainID returns the chain ID with which transactions must be signed, or nil
+// if they are signed without replay protection.
+func (client *client) ChainID() *big.Int {
+	return client.chainID
+}
+
+func (client *client) Client() Backend {
 	return client.client
 }
+
+// Close closes the connection to the network, and stops the health checks of
+// a Pool.
+func (client *client) Close() error {
+	return closeBackend(client.client)
+}
+
+func closeBackend(backend Backend) error {
+	switch backend := backend.(type) {
+	case io.Closer:
+		return backend.Close()
+	case interface{ Close() }:
+		backend.Close()
+	}
+	return nil
+}
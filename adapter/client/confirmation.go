@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Confirmation describes a mined transaction and the number of blocks that
+// have been built on top of the block that includes it.
+type Confirmation struct {
+	BlockNumber   uint64
+	BlockHash     common.Hash
+	Confirmations uint64
+	GasUsed       uint64
+	Receipt       *types.Receipt
+}
+
+// confirmationPollInterval is the time between checks of the chain head
+// while waiting for confirmations.
+var confirmationPollInterval = time.Second
+
+// miner is implemented by backends that only produce blocks when asked to,
+// like the simulated backend.
+type miner interface {
+	Mine()
+}
+
+// WaitConfirmed waits until tx has been mined and the block that includes it
+// has the given number of confirmations, counting that block as the first.
+// If the block is reorganized away, the transaction is tracked again until it
+// is mined into a canonical block. If the transaction has been replaced, see
+// Replace, it waits for whichever replacement is mined. It stops waiting when
+// the context is canceled. On a backend that only mines blocks when asked to,
+// blocks are mined until the transaction has enough confirmations.
+func (b *client) WaitConfirmed(ctx context.Context, tx *types.Transaction, confirmations uint64) (Confirmation, error) {
+	if b.client == nil {
+		return Confirmation{}, ErrNilClient
+	}
+	if tx == nil {
+		return Confirmation{}, ErrNilTx
+	}
+	if confirmations == 0 {
+		confirmations = 1
+	}
+
+	for {
+		mined := false
+		for _, hash := range b.replacements.hashes(tx.Hash()) {
+			conf, err := b.confirmation(ctx, hash)
+			if err == ethereum.NotFound {
+				continue
+			}
+			if err != nil {
+				return Confirmation{}, err
+			}
+			if conf.Confirmations < confirmations {
+				mined = true
+				break
+			}
+			if conf.Receipt.Status != types.ReceiptStatusSuccessful {
+				return conf, TxRevertedError{Hash: hash, Receipt: conf.Receipt}
+			}
+			if hash != tx.Hash() && b.replacements.isCancel(hash) {
+				return conf, TxCanceledError{Hash: tx.Hash(), Cancellation: hash}
+			}
+			return conf, nil
+		}
+
+		if miner, ok := b.client.(miner); ok && mined {
+			miner.Mine()
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return Confirmation{}, ctx.Err()
+		case <-time.After(confirmationPollInterval):
+		}
+	}
+}
+
+// Confirmations returns the number of confirmations after which deposits and
+// withdrawals are treated as final on the network.
+func (b *client) Confirmations() uint64 {
+	if b.confirmations == 0 {
+		return 1
+	}
+	return b.confirmations
+}
+
+// confirmation returns the current confirmation of a transaction. It returns
+// ethereum.NotFound if the transaction is not in a canonical block.
+func (b *client) confirmation(ctx context.Context, txHash common.Hash) (Confirmation, error) {
+	number, hash, err := b.client.ReceiptBlock(ctx, txHash)
+	if err != nil {
+		return Confirmation{}, err
+	}
+	receipt, err := b.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return Confirmation{}, err
+	}
+
+	canonical, err := b.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return Confirmation{}, err
+	}
+	if canonical.Hash() != hash {
+		// The block has been reorganized away, and the node has not yet
+		// indexed the transaction in its new block
+		return Confirmation{}, ethereum.NotFound
+	}
+	head, err := b.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return Confirmation{}, err
+	}
+	if head.Number.Uint64() < number {
+		return Confirmation{}, ethereum.NotFound
+	}
+
+	return Confirmation{
+		BlockNumber:   number,
+		BlockHash:     hash,
+		Confirmations: head.Number.Uint64() - number + 1,
+		GasUsed:       receipt.GasUsed,
+		Receipt:       receipt,
+	}, nil
+}
@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
+)
+
+// SupportedMajorVersion is the major version of the RenEx contracts that the
+// SDK supports. Contract VERSION strings have the form "<network>-<semver>".
+const SupportedMajorVersion = 1
+
+// VersionMismatchError is returned when a contract reports a VERSION that the
+// SDK does not support.
+type VersionMismatchError struct {
+	Contract string
+	Address  common.Address
+	Version  string
+}
+
+func (err VersionMismatchError) Error() string {
+	return fmt.Sprintf("unsupported %s contract at %s: version %q, want major version %d", err.Contract, err.Address.Hex(), err.Version, SupportedMajorVersion)
+}
+
+// DiscoverNetwork fills in the contract addresses that are missing from a
+// network by walking the contract graph from its RenExSettlement or Orderbook
+// address, and checks the VERSION of every contract it visits.
+func DiscoverNetwork(ctx context.Context, backend Backend, network Network) (Network, error) {
+	opts := &bind.CallOpts{Context: ctx}
+
+	if network.RenExSettlementAddress == "" {
+		if network.OrderbookAddress == "" {
+			return Network{}, fmt.Errorf("cannot discover contracts: no RenExSettlement or Orderbook address")
+		}
+		orderbook, err := bindings.NewOrderbookCaller(common.HexToAddress(network.OrderbookAddress), backend)
+		if err != nil {
+			return Network{}, err
+		}
+		registryAddr, err := orderbook.SettlementRegistry(opts)
+		if err != nil {
+			return Network{}, err
+		}
+		settlementAddr, err := findRenExSettlement(ctx, backend, registryAddr)
+		if err != nil {
+			return Network{}, err
+		}
+		network.RenExSettlementAddress = settlementAddr.Hex()
+	}
+
+	settlementAddr := common.HexToAddress(network.RenExSettlementAddress)
+	settlement, err := bindings.NewRenExSettlementCaller(settlementAddr, backend)
+	if err != nil {
+		return Network{}, err
+	}
+	if err := checkVersion("RenExSettlement", settlementAddr, settlement.VERSION, opts); err != nil {
+		return Network{}, err
+	}
+	balancesAddr, err := settlement.RenExBalancesContract(opts)
+	if err != nil {
+		return Network{}, err
+	}
+	tokensAddr, err := settlement.RenExTokensContract(opts)
+	if err != nil {
+		return Network{}, err
+	}
+	orderbookAddr, err := settlement.OrderbookContract(opts)
+	if err != nil {
+		return Network{}, err
+	}
+
+	orderbook, err := bindings.NewOrderbookCaller(orderbookAddr, backend)
+	if err != nil {
+		return Network{}, err
+	}
+	if err := checkVersion("Orderbook", orderbookAddr, orderbook.VERSION, opts); err != nil {
+		return Network{}, err
+	}
+	registryAddr, err := orderbook.SettlementRegistry(opts)
+	if err != nil {
+		return Network{}, err
+	}
+	darknodeRegistryAddr, err := orderbook.DarknodeRegistry(opts)
+	if err != nil {
+		return Network{}, err
+	}
+
+	balances, err := bindings.NewRenExBalancesCaller(balancesAddr, backend)
+	if err != nil {
+		return Network{}, err
+	}
+	if err := checkVersion("RenExBalances", balancesAddr, balances.VERSION, opts); err != nil {
+		return Network{}, err
+	}
+	brokerVerifierAddr, err := balances.BrokerVerifierContract(opts)
+	if err != nil {
+		return Network{}, err
+	}
+
+	tokens, err := bindings.NewRenExTokensCaller(tokensAddr, backend)
+	if err != nil {
+		return Network{}, err
+	}
+	if err := checkVersion("RenExTokens", tokensAddr, tokens.VERSION, opts); err != nil {
+		return Network{}, err
+	}
+	registry, err := bindings.NewSettlementRegistryCaller(registryAddr, backend)
+	if err != nil {
+		return Network{}, err
+	}
+	if err := checkVersion("SettlementRegistry", registryAddr, registry.VERSION, opts); err != nil {
+		return Network{}, err
+	}
+	darknodeRegistry, err := bindings.NewDarknodeRegistryCaller(darknodeRegistryAddr, backend)
+	if err != nil {
+		return Network{}, err
+	}
+	if err := checkVersion("DarknodeRegistry", darknodeRegistryAddr, darknodeRegistry.VERSION, opts); err != nil {
+		return Network{}, err
+	}
+	brokerVerifier, err := bindings.NewRenExBrokerVerifierCaller(brokerVerifierAddr, backend)
+	if err != nil {
+		return Network{}, err
+	}
+	if err := checkVersion("RenExBrokerVerifier", brokerVerifierAddr, brokerVerifier.VERSION, opts); err != nil {
+		return Network{}, err
+	}
+
+	network.OrderbookAddress = orderbookAddr.Hex()
+	network.RenExBalancesAddress = balancesAddr.Hex()
+	network.RenExTokensAddress = tokensAddr.Hex()
+	network.SettlementRegistryAddress = registryAddr.Hex()
+	network.DarknodeRegistryAddress = darknodeRegistryAddr.Hex()
+	network.RenExBrokerVerifierAddress = brokerVerifierAddr.Hex()
+	return network, nil
+}
+
+// findRenExSettlement returns the RenExSettlement contract registered in a
+// SettlementRegistry. Every settlement registered by the registry is checked,
+// and the RenExSettlement contract is the one that is still registered under
+// the RENEXSETTLEMENTID that it reports.
+func findRenExSettlement(ctx context.Context, backend Backend, registryAddr common.Address) (common.Address, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	registry, err := bindings.NewSettlementRegistry(registryAddr, backend)
+	if err != nil {
+		return common.Address{}, err
+	}
+	registered, err := registry.FilterLogSettlementRegistered(&bind.FilterOpts{Context: ctx})
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer registered.Close()
+
+	for registered.Next() {
+		settlement, err := bindings.NewRenExSettlementCaller(registered.Event.SettlementContract, backend)
+		if err != nil {
+			return common.Address{}, err
+		}
+		// Other settlement contracts do not implement RENEXSETTLEMENTID
+		settlementID, err := settlement.RENEXSETTLEMENTID(opts)
+		if err != nil || uint64(settlementID) != registered.Event.SettlementID {
+			continue
+		}
+		current, err := registry.SettlementContract(opts, registered.Event.SettlementID)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if current == registered.Event.SettlementContract {
+			return current, nil
+		}
+	}
+	if err := registered.Error(); err != nil {
+		return common.Address{}, err
+	}
+	return common.Address{}, fmt.Errorf("cannot discover contracts: no RenExSettlement registered in %s", registryAddr.Hex())
+}
+
+// VerifyNetwork checks the VERSION of every contract of a network that has an
+// address. It returns a VersionMismatchError for the first contract with a
+// version that the SDK does not support.
+func VerifyNetwork(ctx context.Context, backend Backend, network Network) error {
+	opts := &bind.CallOpts{Context: ctx}
+	contracts := []struct {
+		name    string
+		address string
+		caller  func(common.Address) (versioned, error)
+	}{
+		{"RenExSettlement", network.RenExSettlementAddress, func(addr common.Address) (versioned, error) {
+			return bindings.NewRenExSettlementCaller(addr, backend)
+		}},
+		{"Orderbook", network.OrderbookAddress, func(addr common.Address) (versioned, error) {
+			return bindings.NewOrderbookCaller(addr, backend)
+		}},
+		{"RenExBalances", network.RenExBalancesAddress, func(addr common.Address) (versioned, error) {
+			return bindings.NewRenExBalancesCaller(addr, backend)
+		}},
+		{"RenExTokens", network.RenExTokensAddress, func(addr common.Address) (versioned, error) {
+			return bindings.NewRenExTokensCaller(addr, backend)
+		}},
+		{"DarknodeRegistry", network.DarknodeRegistryAddress, func(addr common.Address) (versioned, error) {
+			return bindings.NewDarknodeRegistryCaller(addr, backend)
+		}},
+		{"SettlementRegistry", network.SettlementRegistryAddress, func(addr common.Address) (versioned, error) {
+			return bindings.NewSettlementRegistryCaller(addr, backend)
+		}},
+		{"RenExBrokerVerifier", network.RenExBrokerVerifierAddress, func(addr common.Address) (versioned, error) {
+			return bindings.NewRenExBrokerVerifierCaller(addr, backend)
+		}},
+	}
+	for _, contract := range contracts {
+		if contract.address == "" {
+			continue
+		}
+		addr := common.HexToAddress(contract.address)
+		caller, err := contract.caller(addr)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(contract.name, addr, caller.VERSION, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// versioned is implemented by the bindings of every RenEx contract.
+type versioned interface {
+	VERSION(opts *bind.CallOpts) (string, error)
+}
+
+func checkVersion(contract string, addr common.Address, version func(*bind.CallOpts) (string, error), opts *bind.CallOpts) error {
+	v, err := version(opts)
+	if err != nil {
+		return fmt.Errorf("cannot read %s version at %s: %v", contract, addr.Hex(), err)
+	}
+	if major, ok := majorVersion(v); !ok || major != SupportedMajorVersion {
+		return VersionMismatchError{Contract: contract, Address: addr, Version: v}
+	}
+	return nil
+}
+
+// majorVersion parses the major version from a contract VERSION string such
+// as "mainnet-1.0.0".
+func majorVersion(version string) (int, bool) {
+	semver := version[strings.LastIndex(version, "-")+1:]
+	semver = strings.TrimPrefix(semver, "v")
+	major, err := strconv.Atoi(strings.SplitN(semver, ".", 2)[0])
+	if err != nil {
+		return 0, false
+	}
+	return major, true
+}
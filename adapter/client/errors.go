@@ -0,0 +1,53 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrUnknownNetwork is returned when a network is not in the registry.
+var ErrUnknownNetwork = errors.New("Unknown Network")
+
+// ErrNilClient is returned when waiting for a transaction without a
+// connection to Ethereum.
+var ErrNilClient = errors.New("Nil Client")
+
+// ErrNilTx is returned when waiting for a nil transaction.
+var ErrNilTx = errors.New("Nil Tx")
+
+// TxRevertedError is returned when a transaction is mined but reverted.
+type TxRevertedError struct {
+	Hash    common.Hash
+	Receipt *types.Receipt
+}
+
+func (err TxRevertedError) Error() string {
+	return fmt.Sprintf("Transaction reverted: %s", err.Hash.Hex())
+}
+
+// TxCanceledError is returned when waiting for a transaction that has been
+// replaced by a cancellation, once the cancellation is mined.
+type TxCanceledError struct {
+	Hash         common.Hash
+	Cancellation common.Hash
+}
+
+func (err TxCanceledError) Error() string {
+	return fmt.Sprintf("Transaction canceled: %s replaced by %s", err.Hash.Hex(), err.Cancellation.Hex())
+}
+
+// ChainMismatchError is returned when the RPC endpoint of a network is on a
+// different chain than the network is configured for.
+type ChainMismatchError struct {
+	Network string
+	Want    *big.Int
+	Have    *big.Int
+}
+
+func (err ChainMismatchError) Error() string {
+	return fmt.Sprintf("Chain mismatch: network %s is configured for chain %v but the endpoint is on chain %v", err.Network, err.Want, err.Have)
+}
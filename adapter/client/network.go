@@ -0,0 +1,245 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvNetworksFile is the environment variable that names a JSON or YAML file
+// of network definitions. The file is loaded the first time a network is
+// looked up.
+const EnvNetworksFile = "RENEX_NETWORKS"
+
+// Network describes an Ethereum chain on which RenEx is deployed: the RPC
+// endpoints used to reach it, the ingress, and the addresses of the RenEx
+// contracts.
+type Network struct {
+	Name                    string   `json:"name" yaml:"name"`
+	URL                     string   `json:"url" yaml:"url"`
+	FallbackURLs            []string `json:"fallbackUrls" yaml:"fallbackUrls"`
+	Chain                   string   `json:"chain" yaml:"chain"`
+	Ingress                 string   `json:"ingress" yaml:"ingress"`
+	OrderbookAddress        string   `json:"orderbook" yaml:"orderbook"`
+	DarknodeRegistryAddress string   `json:"darknodeRegistry" yaml:"darknodeRegistry"`
+	RenExBalancesAddress    string   `json:"renExBalances" yaml:"renExBalances"`
+	RenExTokensAddress      string   `json:"renExTokens" yaml:"renExTokens"`
+	RenExSettlementAddress  string   `json:"renExSettlement" yaml:"renExSettlement"`
+	RenExAtomicInfoAddress  string   `json:"renExAtomicInfo" yaml:"renExAtomicInfo"`
+
+	SettlementRegistryAddress  string `json:"settlementRegistry" yaml:"settlementRegistry"`
+	RenExBrokerVerifierAddress string `json:"renExBrokerVerifier" yaml:"renExBrokerVerifier"`
+
+	// Confirmations is the number of blocks after which deposits and
+	// withdrawals are treated as final. Zero is treated as one.
+	Confirmations uint64 `json:"confirmations" yaml:"confirmations"`
+
+	// ChainID is the EIP-155 chain ID with which transactions are signed. The
+	// RPC endpoint must be on this chain. Zero skips the check and signs
+	// transactions without replay protection, which is only meant for
+	// development chains.
+	ChainID uint64 `json:"chainId" yaml:"chainId"`
+}
+
+// IngressURL returns the address of the ingress used by the network. It
+// defaults to the hosted RenEx ingress for the network name.
+func (network Network) IngressURL() string {
+	if network.Ingress != "" {
+		return strings.TrimRight(network.Ingress, "/")
+	}
+	return fmt.Sprintf("https://renex-ingress-%s.herokuapp.com", network.Name)
+}
+
+// IsLocal returns true if the network is the in-process development network.
+func (network Network) IsLocal() bool {
+	return network.Chain == NetworkLocal
+}
+
+// complete returns true if the network defines the address of every
+// contract used by the client.
+func (network Network) complete() bool {
+	return network.OrderbookAddress != "" &&
+		network.DarknodeRegistryAddress != "" &&
+		network.RenExBalancesAddress != "" &&
+		network.RenExTokensAddress != "" &&
+		network.RenExSettlementAddress != ""
+}
+
+var (
+	networksMu         = new(sync.RWMutex)
+	networksFileMu     = new(sync.Mutex)
+	networksFileLoaded bool
+	networks           = map[string]Network{
+		"mainnet": {
+			Name:                    "mainnet",
+			URL:                     "https://mainnet.infura.io",
+			Chain:                   "mainnet",
+			ChainID:                 1,
+			Confirmations:           12,
+			DarknodeRegistryAddress: "0x3799006a87fde3ccfc7666b3e6553b03ed341c2f",
+			OrderbookAddress:        "0x6b8bb175c092de7d81860b18db360b734a2598e0",
+			RenExBalancesAddress:    "0x9636f9ac371ca0965b7c2b4ad13c4cc64d0ff2dc",
+			RenExSettlementAddress:  "0x908262de0366e42d029b0518d5276762c92b21e1",
+			RenExTokensAddress:      "0x7cade4fbc8761817bb62a080733d1b6cad744ec4",
+		},
+		"testnet": {
+			Name:                    "testnet",
+			URL:                     "https://kovan.infura.io",
+			Chain:                   "kovan",
+			ChainID:                 42,
+			DarknodeRegistryAddress: "0xf7daA0Baf257547A6Ad3CE7FFF71D55cb7426F76",
+			OrderbookAddress:        "0xA53Da4093c682a4259DE38302341BFEf7e9f7a4f",
+			RenExBalancesAddress:    "0x97073d0d654ebb71dd9efd1dfa777c73f56d4021",
+			RenExSettlementAddress:  "0x68FE2088A321A42DE11Aba93D32C81C9f20b1Abe",
+			RenExTokensAddress:      "0xedFF6E7C072fA0018720734F6d5a4f4DC30f9869",
+		},
+		"falcon": {
+			Name:                    "falcon",
+			URL:                     "https://kovan.infura.io",
+			Chain:                   "kovan",
+			ChainID:                 42,
+			DarknodeRegistryAddress: "0xDaA8C30AF85070506F641E456aFDB84d4bA972Bd",
+			OrderbookAddress:        "0x592d16f8C5FA8f1E074ab3C2cd1ACD087ADcdc0B",
+			RenExBalancesAddress:    "0xb3E632943fA995FC75692e46b62383BE49cDdbc4",
+			RenExSettlementAddress:  "0xBE936cb23DD9a84E4D9358810f7F275e93CCD770",
+			RenExTokensAddress:      "0x9a898c8148131eF189B1c8575692376403780325",
+		},
+		"nightly": {
+			Name:                    "nightly",
+			URL:                     "https://kovan.infura.io",
+			Chain:                   "kovan",
+			ChainID:                 42,
+			DarknodeRegistryAddress: "0x8a31d477267A5af1bc5142904ef0AfA31D326E03",
+			OrderbookAddress:        "0x376127aDc18260fc238eBFB6626b2F4B59eC9b66",
+			RenExBalancesAddress:    "0xa95dE870dDFB6188519D5CC63CEd5E0FBac1aa8E",
+			RenExSettlementAddress:  "0x5f25233ca99104D31612D4fB937B090d5A2EbB75",
+			RenExTokensAddress:      "0x160ECA47935be4139eC5B94D99B678d6f7e18f95",
+		},
+	}
+)
+
+// GetNetwork returns the definition of a registered network with any
+// environment overrides applied.
+func GetNetwork(name string) (Network, error) {
+	if err := loadNetworksFile(); err != nil {
+		return Network{}, err
+	}
+
+	networksMu.RLock()
+	network, ok := networks[name]
+	networksMu.RUnlock()
+	if !ok {
+		return Network{}, ErrUnknownNetwork
+	}
+	return applyEnvOverrides(network), nil
+}
+
+// loadNetworksFile loads the file named by EnvNetworksFile once it has been
+// read successfully. A file that cannot be loaded is read again the next time
+// a network is looked up.
+func loadNetworksFile() error {
+	networksFileMu.Lock()
+	defer networksFileMu.Unlock()
+
+	if networksFileLoaded {
+		return nil
+	}
+	if path := os.Getenv(EnvNetworksFile); path != "" {
+		if err := LoadNetworks(path); err != nil {
+			return err
+		}
+	}
+	networksFileLoaded = true
+	return nil
+}
+
+// RegisterNetwork adds a network definition to the registry, replacing any
+// existing definition with the same name.
+func RegisterNetwork(network Network) error {
+	if network.Name == "" {
+		return fmt.Errorf("Network name is required")
+	}
+	networksMu.Lock()
+	defer networksMu.Unlock()
+	networks[network.Name] = network
+	return nil
+}
+
+// LoadNetworks registers every network defined in a JSON or YAML file. The
+// file holds a map from network names to definitions. The format is chosen by
+// the file extension, and YAML is assumed for anything other than ".json".
+func LoadNetworks(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	defs := map[string]Network{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &defs)
+	default:
+		err = yaml.Unmarshal(data, &defs)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot load networks from %s: %v", path, err)
+	}
+
+	for name, network := range defs {
+		if network.Name == "" {
+			network.Name = name
+		}
+		if err := RegisterNetwork(network); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEnvOverrides replaces fields of the network with the values of the
+// RENEX_<NAME>_<FIELD> environment variables, for example
+// RENEX_TESTNET_URL or RENEX_TESTNET_ORDERBOOK. RENEX_<NAME>_FALLBACK_URLS
+// is a comma separated list, and RENEX_<NAME>_CONFIRMATIONS and
+// RENEX_<NAME>_CHAIN_ID are numbers.
+func applyEnvOverrides(network Network) Network {
+	prefix := "RENEX_" + strings.ToUpper(strings.Replace(network.Name, "-", "_", -1)) + "_"
+	fields := map[string]*string{
+		"URL":                   &network.URL,
+		"CHAIN":                 &network.Chain,
+		"INGRESS":               &network.Ingress,
+		"ORDERBOOK":             &network.OrderbookAddress,
+		"DARKNODE_REGISTRY":     &network.DarknodeRegistryAddress,
+		"RENEX_BALANCES":        &network.RenExBalancesAddress,
+		"RENEX_TOKENS":          &network.RenExTokensAddress,
+		"RENEX_SETTLEMENT":      &network.RenExSettlementAddress,
+		"RENEX_ATOMIC_INFO":     &network.RenExAtomicInfoAddress,
+		"SETTLEMENT_REGISTRY":   &network.SettlementRegistryAddress,
+		"RENEX_BROKER_VERIFIER": &network.RenExBrokerVerifierAddress,
+	}
+	for suffix, field := range fields {
+		if value, ok := os.LookupEnv(prefix + suffix); ok {
+			*field = value
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "FALLBACK_URLS"); ok {
+		network.FallbackURLs = strings.Split(value, ",")
+	}
+	if value, ok := os.LookupEnv(prefix + "CONFIRMATIONS"); ok {
+		if confirmations, err := strconv.ParseUint(value, 10, 64); err == nil {
+			network.Confirmations = confirmations
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "CHAIN_ID"); ok {
+		if chainID, err := strconv.ParseUint(value, 10, 64); err == nil {
+			network.ChainID = chainID
+		}
+	}
+	return network
+}
@@ -0,0 +1,512 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrNoHealthyEndpoint is returned when every endpoint in a pool has failed
+// or has its circuit breaker open.
+var ErrNoHealthyEndpoint = errors.New("no healthy endpoint")
+
+// PoolConfig configures the health checks and circuit breakers of a Pool.
+type PoolConfig struct {
+	// HealthCheckInterval is the time between checks of every endpoint.
+	HealthCheckInterval time.Duration
+
+	// HealthCheckTimeout bounds a single health check.
+	HealthCheckTimeout time.Duration
+
+	// MaxBlockLag is the number of blocks an endpoint can fall behind the
+	// highest endpoint before it is only used as a last resort.
+	MaxBlockLag uint64
+
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit breaker of an endpoint.
+	FailureThreshold int
+
+	// Cooldown is the time an open circuit breaker waits before the endpoint
+	// is tried again.
+	Cooldown time.Duration
+
+	// ChainID is the chain that every endpoint must be on. When it is nil,
+	// the endpoints must be on the chain of the first endpoint that reports
+	// its chain. An endpoint on another chain is never used.
+	ChainID *big.Int
+}
+
+// DefaultPoolConfig is used for every zero field of a PoolConfig.
+var DefaultPoolConfig = PoolConfig{
+	HealthCheckInterval: 15 * time.Second,
+	HealthCheckTimeout:  5 * time.Second,
+	MaxBlockLag:         5,
+	FailureThreshold:    3,
+	Cooldown:            30 * time.Second,
+}
+
+type endpoint struct {
+	url      string
+	client   *rpcBackend
+	height   uint64
+	failures int
+	open     bool
+	openedAt time.Time
+
+	// verified is set once the endpoint reports the chain of the pool, and
+	// wrongChain once it reports another chain
+	verified   bool
+	wrongChain bool
+}
+
+// Pool is a Backend that spreads calls over a set of RPC endpoints. Endpoints
+// are health checked in the background, endpoints that fall behind the chain
+// head are avoided, and a failed call is retried on the next endpoint. Every
+// endpoint is checked to be on the chain of the pool when it is dialed, and
+// is only used once it is.
+type Pool struct {
+	config PoolConfig
+
+	mu        *sync.Mutex
+	endpoints []*endpoint
+	chainID   *big.Int
+
+	done      chan struct{}
+	closeOnce *sync.Once
+}
+
+// NewPool dials every URL and starts health checking them. Endpoints that
+// cannot be dialed are retried by the health checks.
+func NewPool(urls []string, config PoolConfig) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("cannot create pool: no endpoints")
+	}
+	config = config.withDefaults()
+
+	pool := &Pool{
+		config:    config,
+		mu:        new(sync.Mutex),
+		endpoints: make([]*endpoint, 0, len(urls)),
+		chainID:   config.ChainID,
+		done:      make(chan struct{}),
+		closeOnce: new(sync.Once),
+	}
+	for _, rawurl := range urls {
+		ep := &endpoint{url: rawurl}
+		if client, err := dial(rawurl); err == nil {
+			ep.client = client
+		} else {
+			pool.trip(ep)
+		}
+		pool.endpoints = append(pool.endpoints, ep)
+	}
+
+	pool.check()
+	go pool.run()
+	return pool, nil
+}
+
+// Close stops the health checks and closes the connection to every endpoint.
+func (pool *Pool) Close() error {
+	pool.closeOnce.Do(func() {
+		close(pool.done)
+
+		pool.mu.Lock()
+		defer pool.mu.Unlock()
+		for _, ep := range pool.endpoints {
+			if ep.client != nil {
+				ep.client.Close()
+			}
+		}
+	})
+	return nil
+}
+
+func (pool *Pool) run() {
+	ticker := time.NewTicker(pool.config.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pool.done:
+			return
+		case <-ticker.C:
+			pool.check()
+		}
+	}
+}
+
+// check fetches the latest block height of every endpoint, redialing
+// endpoints that could not be dialed before and verifying the chain of
+// endpoints that have not been verified. Endpoints on the wrong chain are not
+// checked again.
+func (pool *Pool) check() {
+	pool.mu.Lock()
+	endpoints := make([]*endpoint, len(pool.endpoints))
+	copy(endpoints, pool.endpoints)
+	pool.mu.Unlock()
+
+	wg := new(sync.WaitGroup)
+	for _, ep := range endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+
+			pool.mu.Lock()
+			client, verified, wrongChain := ep.client, ep.verified, ep.wrongChain
+			pool.mu.Unlock()
+			if wrongChain {
+				return
+			}
+			if client == nil {
+				dialed, err := dial(ep.url)
+				if err != nil {
+					pool.failure(ep)
+					return
+				}
+				pool.mu.Lock()
+				ep.client = dialed
+				pool.mu.Unlock()
+				client = dialed
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), pool.config.HealthCheckTimeout)
+			defer cancel()
+			if !verified {
+				if err := pool.verify(ctx, ep, client); err != nil {
+					pool.failure(ep)
+					return
+				}
+			}
+			header, err := client.HeaderByNumber(ctx, nil)
+			if err != nil {
+				pool.failure(ep)
+				return
+			}
+			pool.mu.Lock()
+			ep.height = header.Number.Uint64()
+			pool.mu.Unlock()
+			pool.success(ep)
+		}(ep)
+	}
+	wg.Wait()
+}
+
+// verify checks that an endpoint is on the chain of the pool. The first
+// endpoint to report its chain sets the chain of a pool without a ChainID. An
+// endpoint on another chain is closed and marked so that it is never used.
+func (pool *Pool) verify(ctx context.Context, ep *endpoint, client *rpcBackend) error {
+	have, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.chainID == nil {
+		pool.chainID = have
+	}
+	if have.Cmp(pool.chainID) != 0 {
+		ep.wrongChain = true
+		ep.client = nil
+		client.Close()
+		return fmt.Errorf("endpoint %s is on chain %v, not chain %v", ep.url, have, pool.chainID)
+	}
+	ep.verified = true
+	return nil
+}
+
+// candidates returns the endpoints that can be called, best first. Endpoints
+// within MaxBlockLag of the highest endpoint come before lagging endpoints.
+// Endpoints with an open circuit breaker are skipped until their cooldown has
+// passed, and are then tried last.
+func (pool *Pool) candidates() []*endpoint {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	best := uint64(0)
+	for _, ep := range pool.endpoints {
+		if !ep.open && ep.height > best {
+			best = ep.height
+		}
+	}
+
+	synced := []*endpoint{}
+	lagging := []*endpoint{}
+	trials := []*endpoint{}
+	for _, ep := range pool.endpoints {
+		switch {
+		case ep.client == nil, !ep.verified, ep.wrongChain:
+		case ep.open && time.Since(ep.openedAt) >= pool.config.Cooldown:
+			trials = append(trials, ep)
+		case ep.open:
+		case ep.height+pool.config.MaxBlockLag < best:
+			lagging = append(lagging, ep)
+		default:
+			synced = append(synced, ep)
+		}
+	}
+	return append(append(synced, lagging...), trials...)
+}
+
+func (pool *Pool) success(ep *endpoint) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	ep.failures = 0
+	ep.open = false
+}
+
+func (pool *Pool) failure(ep *endpoint) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	ep.failures++
+	if ep.failures >= pool.config.FailureThreshold {
+		ep.open = true
+		ep.openedAt = time.Now()
+	}
+}
+
+func (pool *Pool) trip(ep *endpoint) {
+	ep.failures = pool.config.FailureThreshold
+	ep.open = true
+	ep.openedAt = time.Now()
+}
+
+// do calls f on the best endpoint, failing over to the next endpoint when the
+// call fails because the endpoint is unavailable. Errors returned by a
+// healthy endpoint, such as a reverted call, are returned immediately.
+func (pool *Pool) do(ctx context.Context, f func(*rpcBackend) error) error {
+	err := ErrNoHealthyEndpoint
+	for _, ep := range pool.candidates() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		pool.mu.Lock()
+		client := ep.client
+		pool.mu.Unlock()
+		if err = f(client); err == nil || !isEndpointError(ctx, err) {
+			pool.success(ep)
+			return err
+		}
+		pool.failure(ep)
+	}
+	return err
+}
+
+// isEndpointError returns true if err was caused by the endpoint being
+// unavailable, rather than by the request itself.
+func isEndpointError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == context.DeadlineExceeded {
+		return true
+	}
+	switch err.(type) {
+	case net.Error, *url.Error:
+		return true
+	}
+	if status, ok := httpStatus(err); ok {
+		switch status {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// httpStatus returns the status code of an HTTP response that the RPC client
+// rejected. The RPC client reports a response without a 2xx status code with
+// an error that only holds the status line, such as "503 Service
+// Unavailable".
+func httpStatus(err error) (int, bool) {
+	msg := err.Error()
+	if len(msg) < 3 || (len(msg) > 3 && msg[3] != ' ') {
+		return 0, false
+	}
+	status, convErr := strconv.Atoi(msg[:3])
+	if convErr != nil || http.StatusText(status) == "" {
+		return 0, false
+	}
+	if len(msg) > 4 && !strings.EqualFold(msg[4:], http.StatusText(status)) {
+		return 0, false
+	}
+	return status, true
+}
+
+// IsKnownTransaction returns true if a node rejected a transaction because it
+// already has it, which happens when a transaction is sent again after a
+// failed attempt that still reached the node.
+func IsKnownTransaction(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.HasPrefix(msg, "known transaction") ||
+		strings.HasPrefix(msg, "already known") ||
+		strings.Contains(msg, "transaction with the same hash was already imported")
+}
+
+func (pool *Pool) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		code, err = client.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (pool *Pool) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (ret []byte, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		ret, err = client.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return ret, err
+}
+
+func (pool *Pool) PendingCodeAt(ctx context.Context, account common.Address) (code []byte, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		code, err = client.PendingCodeAt(ctx, account)
+		return err
+	})
+	return code, err
+}
+
+func (pool *Pool) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		nonce, err = client.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (pool *Pool) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		price, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+func (pool *Pool) EstimateGas(ctx context.Context, call ethereum.CallMsg) (gas uint64, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		gas, err = client.EstimateGas(ctx, call)
+		return err
+	})
+	return gas, err
+}
+
+// SendTransaction sends a transaction to the best endpoint. A transaction that
+// is failed over to another endpoint may already have reached the endpoint
+// that failed, and then reached the rest of the network, so a node that
+// already knows the transaction is treated as a success.
+func (pool *Pool) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return pool.do(ctx, func(client *rpcBackend) error {
+		if err := client.SendTransaction(ctx, tx); err != nil && !IsKnownTransaction(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+func (pool *Pool) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (logs []types.Log, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		logs, err = client.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+func (pool *Pool) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (sub ethereum.Subscription, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		sub, err = client.SubscribeFilterLogs(ctx, query, ch)
+		return err
+	})
+	return sub, err
+}
+
+func (pool *Pool) TransactionReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		receipt, err = client.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+func (pool *Pool) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		tx, isPending, err = client.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+func (pool *Pool) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		header, err = client.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+func (pool *Pool) BlockByNumber(ctx context.Context, number *big.Int) (block *types.Block, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		block, err = client.BlockByNumber(ctx, number)
+		return err
+	})
+	return block, err
+}
+
+func (pool *Pool) ReceiptBlock(ctx context.Context, txHash common.Hash) (number uint64, hash common.Hash, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		number, hash, err = client.ReceiptBlock(ctx, txHash)
+		return err
+	})
+	return number, hash, err
+}
+
+func (pool *Pool) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		balance, err = client.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+func (pool *Pool) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (nonce uint64, err error) {
+	err = pool.do(ctx, func(client *rpcBackend) error {
+		nonce, err = client.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
+
+func (config PoolConfig) withDefaults() PoolConfig {
+	if config.HealthCheckInterval == 0 {
+		config.HealthCheckInterval = DefaultPoolConfig.HealthCheckInterval
+	}
+	if config.HealthCheckTimeout == 0 {
+		config.HealthCheckTimeout = DefaultPoolConfig.HealthCheckTimeout
+	}
+	if config.MaxBlockLag == 0 {
+		config.MaxBlockLag = DefaultPoolConfig.MaxBlockLag
+	}
+	if config.FailureThreshold == 0 {
+		config.FailureThreshold = DefaultPoolConfig.FailureThreshold
+	}
+	if config.Cooldown == 0 {
+		config.Cooldown = DefaultPoolConfig.Cooldown
+	}
+	return config
+}
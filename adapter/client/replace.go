@@ -0,0 +1,76 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// replacements records the transactions that replace each other at the same
+// nonce, so that waiting for any one of them waits for whichever is mined.
+type replacements struct {
+	mu       *sync.Mutex
+	families map[common.Hash]*family
+}
+
+type family struct {
+	hashes   []common.Hash
+	canceled map[common.Hash]bool
+}
+
+func newReplacements() *replacements {
+	return &replacements{
+		mu:       new(sync.Mutex),
+		families: map[common.Hash]*family{},
+	}
+}
+
+func (r *replacements) add(original, replacement common.Hash, cancel bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[original]
+	if !ok {
+		f = &family{
+			hashes:   []common.Hash{original},
+			canceled: map[common.Hash]bool{},
+		}
+		r.families[original] = f
+	}
+	if _, ok := r.families[replacement]; !ok {
+		f.hashes = append(f.hashes, replacement)
+		r.families[replacement] = f
+	}
+	f.canceled[replacement] = cancel
+}
+
+// hashes returns the hashes of every transaction that replaces, or is
+// replaced by, a transaction.
+func (r *replacements) hashes(hash common.Hash) []common.Hash {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[hash]
+	if !ok {
+		return []common.Hash{hash}
+	}
+	hashes := make([]common.Hash, len(f.hashes))
+	copy(hashes, f.hashes)
+	return hashes
+}
+
+func (r *replacements) isCancel(hash common.Hash) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[hash]
+	return ok && f.canceled[hash]
+}
+
+// Replace records that a transaction has been replaced by another transaction
+// at the same nonce. Waiting for either transaction waits for whichever of
+// them is mined. If the replacement cancels the original, waiting for the
+// original returns a TxCanceledError when the cancellation is mined.
+func (b *client) Replace(original, replacement common.Hash, cancel bool) {
+	b.replacements.add(original, replacement, cancel)
+}
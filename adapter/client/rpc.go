@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcBackend is an ethclient that keeps its underlying RPC connection, so that
+// it can read the fields of a receipt that the ethclient drops.
+type rpcBackend struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+func dial(rawurl string) (*rpcBackend, error) {
+	conn, err := rpc.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcBackend{
+		Client: ethclient.NewClient(conn),
+		rpc:    conn,
+	}, nil
+}
+
+// ReceiptBlock returns the number and hash of the block in which a transaction
+// was mined. It returns ethereum.NotFound if the transaction has not been
+// mined, including when the node returns a receipt for a pending transaction.
+func (backend *rpcBackend) ReceiptBlock(ctx context.Context, txHash common.Hash) (uint64, common.Hash, error) {
+	var receipt *struct {
+		BlockNumber *hexutil.Big `json:"blockNumber"`
+		BlockHash   *common.Hash `json:"blockHash"`
+	}
+	if err := backend.rpc.CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+		return 0, common.Hash{}, err
+	}
+	if receipt == nil || receipt.BlockNumber == nil || receipt.BlockHash == nil {
+		return 0, common.Hash{}, ethereum.NotFound
+	}
+	return receipt.BlockNumber.ToInt().Uint64(), *receipt.BlockHash, nil
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NetworkLocal is the name and chain of the in-process development network.
+const NetworkLocal = "local"
+
+// simulatedReplacementBump is the percentage by which a transaction must raise
+// the gas price of a pending transaction with the same nonce to replace it,
+// which is the default of geth.
+const simulatedReplacementBump = 10
+
+type simulatedTx struct {
+	tx   *types.Transaction
+	from common.Address
+}
+
+type simulatedBackend struct {
+	*backends.SimulatedBackend
+
+	mu      *sync.RWMutex
+	pending []simulatedTx
+	txs     map[common.Hash]*types.Transaction
+	blocks  map[common.Hash]uint64
+	height  uint64
+}
+
+// NewSimulatedBackend wraps a go-ethereum simulated backend so that it can be
+// used as a Backend. Sent transactions are kept pending, and can be replaced
+// at the same nonce, until they are mined. A block with every pending
+// transaction is mined as soon as a client waits for one of them, or when Mine
+// is called.
+func NewSimulatedBackend(sim *backends.SimulatedBackend) Backend {
+	return &simulatedBackend{
+		SimulatedBackend: sim,
+		mu:               new(sync.RWMutex),
+		pending:          []simulatedTx{},
+		txs:              map[common.Hash]*types.Transaction{},
+		blocks:           map[common.Hash]uint64{},
+	}
+}
+
+// NewSimulatedClient creates a client for the contracts of a network that
+// have been deployed to a simulated backend. Transactions are signed without
+// replay protection, since the simulated backend only accepts Homestead
+// signatures.
+func NewSimulatedClient(backend Backend, network Network) Client {
+	return &client{
+		client:           backend,
+		network:          NetworkLocal,
+		chain:            NetworkLocal,
+		orderbook:        common.HexToAddress(network.OrderbookAddress),
+		darknodeRegistry: common.HexToAddress(network.DarknodeRegistryAddress),
+		renExBalances:    common.HexToAddress(network.RenExBalancesAddress),
+		renExTokens:      common.HexToAddress(network.RenExTokensAddress),
+		renExSettlement:  common.HexToAddress(network.RenExSettlementAddress),
+		confirmations:    network.Confirmations,
+		replacements:     newReplacements(),
+	}
+}
+
+// SendTransaction adds a transaction to the pending transactions. Like a node,
+// it rejects transactions that are already known, that do not use the next
+// nonce of the sender, or that replace a pending transaction without raising
+// its gas price enough.
+func (b *simulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	from, err := types.Sender(types.HomesteadSigner{}, tx)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.txs[tx.Hash()]; ok {
+		return fmt.Errorf("known transaction: %x", tx.Hash())
+	}
+	for i, pending := range b.pending {
+		if pending.from != from || pending.tx.Nonce() != tx.Nonce() {
+			continue
+		}
+		min := new(big.Int).Mul(pending.tx.GasPrice(), big.NewInt(100+simulatedReplacementBump))
+		if tx.GasPrice().Cmp(min.Div(min, big.NewInt(100))) < 0 {
+			return errors.New("replacement transaction underpriced")
+		}
+		delete(b.txs, pending.tx.Hash())
+		b.pending[i] = simulatedTx{tx: tx, from: from}
+		b.txs[tx.Hash()] = tx
+		return nil
+	}
+
+	nonce, err := b.pendingNonceAt(ctx, from)
+	if err != nil {
+		return err
+	}
+	if tx.Nonce() < nonce {
+		return errors.New("nonce too low")
+	}
+	if tx.Nonce() > nonce {
+		return errors.New("nonce too high")
+	}
+	b.pending = append(b.pending, simulatedTx{tx: tx, from: from})
+	b.txs[tx.Hash()] = tx
+	return nil
+}
+
+// PendingNonceAt returns the next nonce of an account, including its pending
+// transactions.
+func (b *simulatedBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.pendingNonceAt(ctx, account)
+}
+
+func (b *simulatedBackend) pendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	nonce, err := b.SimulatedBackend.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+	for _, pending := range b.pending {
+		if pending.from == account {
+			nonce++
+		}
+	}
+	return nonce, nil
+}
+
+func (b *simulatedBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	tx, ok := b.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	_, mined := b.blocks[hash]
+	return tx, !mined, nil
+}
+
+// Mine mines a block with every pending transaction. A block is mined even
+// when no transaction is pending.
+func (b *simulatedBackend) Mine() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mine()
+}
+
+func (b *simulatedBackend) mine() {
+	for _, pending := range b.pending {
+		// The nonces of pending transactions are checked when they are sent,
+		// so the simulated backend accepts every one of them
+		b.SimulatedBackend.SendTransaction(context.Background(), pending.tx)
+	}
+	b.Commit()
+	b.height++
+	for _, pending := range b.pending {
+		b.blocks[pending.tx.Hash()] = b.height
+	}
+	b.pending = []simulatedTx{}
+}
+
+// HeaderByNumber returns a header that only holds the block number. The
+// go-ethereum simulated backend does not expose its blocks, and the simulated
+// chain never reorganizes, so the number is all that is needed to track
+// confirmations.
+func (b *simulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if number == nil {
+		return simulatedHeader(b.height), nil
+	}
+	if !number.IsUint64() || number.Uint64() > b.height {
+		return nil, ethereum.NotFound
+	}
+	return simulatedHeader(number.Uint64()), nil
+}
+
+// ReceiptBlock returns the block that includes a transaction. A pending
+// transaction is mined first, since the caller is waiting for it.
+func (b *simulatedBackend) ReceiptBlock(ctx context.Context, txHash common.Hash) (uint64, common.Hash, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.txs[txHash]; ok {
+		if _, mined := b.blocks[txHash]; !mined {
+			b.mine()
+		}
+	}
+	number, ok := b.blocks[txHash]
+	if !ok {
+		return 0, common.Hash{}, ethereum.NotFound
+	}
+	return number, simulatedHeader(number).Hash(), nil
+}
+
+func simulatedHeader(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number)}
+}
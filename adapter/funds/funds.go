@@ -1,17 +1,12 @@
 package funds
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io/ioutil"
 	"math/big"
-	"net/http"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -19,6 +14,8 @@ import (
 	"github.com/republicprotocol/renex-ingress-go/httpadapter"
 	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
 	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/ingress"
+	"github.com/republicprotocol/renex-sdk-go/adapter/journal"
 	"github.com/republicprotocol/renex-sdk-go/adapter/store"
 	"github.com/republicprotocol/renex-sdk-go/adapter/trader"
 	"github.com/republicprotocol/renex-sdk-go/core/funds"
@@ -30,11 +27,12 @@ type adapter struct {
 	renExTokensContract   *bindings.RenExTokens
 	client                client.Client
 	trader                trader.Trader
-	httpAddress           string
+	ingressClient         *ingress.Client
+	journal               journal.Journal
 	store.Store
 }
 
-func NewAdapter(httpAddress string, client client.Client, trader trader.Trader, store store.Store) (funds.Adapter, error) {
+func NewAdapter(ingressClient *ingress.Client, client client.Client, trader trader.Trader, store store.Store, journal journal.Journal) (funds.Adapter, error) {
 	renExBalances, err := bindings.NewRenExBalances(client.RenExBalancesAddress(), bind.ContractBackend(client.Client()))
 	if err != nil {
 		return nil, err
@@ -46,25 +44,32 @@ func NewAdapter(httpAddress string, client client.Client, trader trader.Trader,
 	return &adapter{
 		renExBalancesContract: renExBalances,
 		renExTokensContract:   renExTokens,
-		httpAddress:           httpAddress,
+		ingressClient:         ingressClient,
+		journal:               journal,
 		trader:                trader,
 		Store:                 store,
 		client:                client,
 	}, nil
 }
 
-func (adapter *adapter) RequestWithdrawalWithSignature(tokenCode order.Token, value *big.Int, signature []byte) error {
-	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{}, uint32(tokenCode))
+func (adapter *adapter) RequestWithdrawalWithSignature(ctx context.Context, tokenCode order.Token, value *big.Int, signature []byte) error {
+	return adapter.journaled(ctx, journal.KindWithdraw, tokenCode, value, func(ctx context.Context) error {
+		return adapter.requestWithdrawalWithSignature(step(ctx, journal.StepWithdraw), tokenCode, value, signature)
+	})
+}
+
+func (adapter *adapter) requestWithdrawalWithSignature(ctx context.Context, tokenCode order.Token, value *big.Int, signature []byte) error {
+	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
 	if err != nil {
 		return err
 	}
 
 	if !token.Registered {
-		return fmt.Errorf("Unregistered token")
+		return funds.ErrUnregisteredToken
 	}
 
-	tx, err := adapter.trader.SendTx(func() (client.Client, *types.Transaction, error) {
-		tx, err := adapter.renExBalancesContract.Withdraw(adapter.trader.TransactOpts(), token.Addr, value, signature)
+	tx, err := adapter.trader.SendTx(ctx, func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		tx, err := adapter.renExBalancesContract.Withdraw(opts, token.Addr, value, signature)
 		return adapter.client, tx, err
 	})
 
@@ -72,31 +77,41 @@ func (adapter *adapter) RequestWithdrawalWithSignature(tokenCode order.Token, va
 		return err
 	}
 
-	if _, err := adapter.client.WaitTillMined(context.Background(), tx); err != nil {
+	if _, err := adapter.client.WaitConfirmed(ctx, tx, adapter.client.Confirmations()); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (adapter *adapter) RequestWithdrawalFailSafeTrigger(tokenCode order.Token) (*funds.IdempotentKey, error) {
-	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{}, uint32(tokenCode))
+func (adapter *adapter) RequestWithdrawalFailSafeTrigger(ctx context.Context, tokenCode order.Token) (*funds.IdempotentKey, error) {
+	var key *funds.IdempotentKey
+	err := adapter.journaled(ctx, journal.KindWithdraw, tokenCode, nil, func(ctx context.Context) error {
+		var err error
+		key, err = adapter.requestWithdrawalFailSafeTrigger(step(ctx, journal.StepSignal), tokenCode)
+		return err
+	})
+	return key, err
+}
+
+func (adapter *adapter) requestWithdrawalFailSafeTrigger(ctx context.Context, tokenCode order.Token) (*funds.IdempotentKey, error) {
+	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
 	if err != nil {
 		return nil, err
 	}
 
 	if !token.Registered {
-		return nil, fmt.Errorf("Unregistered token")
+		return nil, funds.ErrUnregisteredToken
 	}
 
-	tx, err := adapter.trader.SendTx(func() (client.Client, *types.Transaction, error) {
-		tx, err := adapter.renExBalancesContract.SignalBackupWithdraw(adapter.trader.TransactOpts(), token.Addr)
+	tx, err := adapter.trader.SendTx(ctx, func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		tx, err := adapter.renExBalancesContract.SignalBackupWithdraw(opts, token.Addr)
 		return adapter.client, tx, err
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := adapter.client.WaitTillMined(context.Background(), tx); err != nil {
+	if _, err := adapter.client.WaitConfirmed(ctx, tx, adapter.client.Confirmations()); err != nil {
 		return nil, err
 	}
 
@@ -108,99 +123,77 @@ func (adapter *adapter) RequestWithdrawalFailSafeTrigger(tokenCode order.Token)
 	return &key, nil
 }
 
-func (adapter *adapter) RequestWithdrawalFailSafe(tokenCode order.Token, value *big.Int) error {
-	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{}, uint32(tokenCode))
+func (adapter *adapter) RequestWithdrawalFailSafe(ctx context.Context, tokenCode order.Token, value *big.Int) error {
+	return adapter.journaled(ctx, journal.KindWithdraw, tokenCode, value, func(ctx context.Context) error {
+		return adapter.requestWithdrawalFailSafe(step(ctx, journal.StepWithdraw), tokenCode, value)
+	})
+}
+
+func (adapter *adapter) requestWithdrawalFailSafe(ctx context.Context, tokenCode order.Token, value *big.Int) error {
+	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
 	if err != nil {
 		return err
 	}
 
 	if !token.Registered {
-		return fmt.Errorf("Unregistered token")
+		return funds.ErrUnregisteredToken
 	}
 
-	tx, err := adapter.trader.SendTx(func() (client.Client, *types.Transaction, error) {
-		tx, err := adapter.renExBalancesContract.Withdraw(adapter.trader.TransactOpts(), token.Addr, value, []byte{})
+	tx, err := adapter.trader.SendTx(ctx, func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		tx, err := adapter.renExBalancesContract.Withdraw(opts, token.Addr, value, []byte{})
 		return adapter.client, tx, err
 	})
 	if err != nil {
 		return err
 	}
 
-	if _, err := adapter.client.WaitTillMined(context.Background(), tx); err != nil {
+	if _, err := adapter.client.WaitConfirmed(ctx, tx, adapter.client.Confirmations()); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (adapter *adapter) RequestWithdrawalSignature(tokenCode order.Token, value *big.Int) ([]byte, error) {
-	req := httpadapter.ApproveWithdrawalRequest{
+func (adapter *adapter) RequestWithdrawalSignature(ctx context.Context, tokenCode order.Token, value *big.Int) ([]byte, error) {
+	return adapter.ingressClient.ApproveWithdrawal(ctx, httpadapter.ApproveWithdrawalRequest{
 		Trader:  adapter.trader.Address().String()[2:],
 		TokenID: uint32(tokenCode),
-	}
-
-	data, err := json.MarshalIndent(req, "", "  ")
-	if err != nil {
-		return nil, err
-	}
-	buf := bytes.NewBuffer(data)
-
-	resp, err := http.DefaultClient.Post(fmt.Sprintf("%s/withdrawals", adapter.httpAddress), "application/json", buf)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	if !(resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK) {
-		return nil, fmt.Errorf("Unexpected status code %d: %s %s", resp.StatusCode, resp.Status, string(respBytes))
-	}
-
-	type Response struct {
-		Signature string `json:"signature"`
-	}
-
-	response := Response{}
-	if err := json.Unmarshal(respBytes, &response); err != nil {
-		return nil, err
-	}
+	})
+}
 
-	return base64.StdEncoding.DecodeString(response.Signature)
+func (adapter *adapter) RequestDeposit(ctx context.Context, tokenCode order.Token, value *big.Int) error {
+	return adapter.journaled(ctx, journal.KindDeposit, tokenCode, value, func(ctx context.Context) error {
+		return adapter.requestDeposit(ctx, tokenCode, value)
+	})
 }
 
-func (adapter *adapter) RequestDeposit(tokenCode order.Token, value *big.Int) error {
-	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{}, uint32(tokenCode))
+func (adapter *adapter) requestDeposit(ctx context.Context, tokenCode order.Token, value *big.Int) error {
+	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
 	if err != nil {
 		return err
 	}
 
 	if !token.Registered {
-		return fmt.Errorf("Unregistered token")
+		return funds.ErrUnregisteredToken
 	}
 
-	addr, err := adapter.renExBalancesContract.ETHEREUM(&bind.CallOpts{})
+	addr, err := adapter.renExBalancesContract.ETHEREUM(&bind.CallOpts{Context: ctx})
 	if err != nil {
 		return err
 	}
 
 	if addr.String() == token.Addr.String() {
-		auth := adapter.trader.TransactOpts()
-		auth.Value = value
-
-		tx, err := adapter.trader.SendTx(func() (client.Client, *types.Transaction, error) {
-			tx, err := adapter.renExBalancesContract.Deposit(auth, token.Addr, value)
+		tx, err := adapter.trader.SendTx(step(ctx, journal.StepDeposit), func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+			opts.Value = value
+			tx, err := adapter.renExBalancesContract.Deposit(opts, token.Addr, value)
 			return adapter.client, tx, err
 		})
 		if err != nil {
 			return err
 		}
 		if tx == nil {
-			return fmt.Errorf("Nil Eth Transaction")
+			return funds.ErrNilTransaction
 		}
-		if _, err := adapter.client.WaitTillMined(context.Background(), tx); err != nil {
+		if _, err := adapter.client.WaitConfirmed(ctx, tx, adapter.client.Confirmations()); err != nil {
 			return err
 		}
 		return nil
@@ -211,43 +204,51 @@ func (adapter *adapter) RequestDeposit(tokenCode order.Token, value *big.Int) er
 		return err
 	}
 
-	tx, err := adapter.trader.SendTx(func() (client.Client, *types.Transaction, error) {
-		tx, err := tokenContract.Approve(adapter.trader.TransactOpts(), adapter.client.RenExBalancesAddress(), value)
-		return adapter.client, tx, err
-	})
+	// The approval is skipped if it was already mined by a deposit that did
+	// not complete
+	allowance, err := tokenContract.Allowance(&bind.CallOpts{Context: ctx}, adapter.trader.Address(), adapter.client.RenExBalancesAddress())
 	if err != nil {
 		return err
 	}
-	if tx == nil {
-		return fmt.Errorf("Nil Approve Transaction")
-	}
-	if _, err := adapter.client.WaitTillMined(context.Background(), tx); err != nil {
-		return err
+	if allowance.Cmp(value) < 0 {
+		tx, err := adapter.trader.SendTx(step(ctx, journal.StepApprove), func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+			tx, err := tokenContract.Approve(opts, adapter.client.RenExBalancesAddress(), value)
+			return adapter.client, tx, err
+		})
+		if err != nil {
+			return err
+		}
+		if tx == nil {
+			return funds.ErrNilTransaction
+		}
+		if _, err := adapter.client.WaitTillMined(ctx, tx); err != nil {
+			return err
+		}
 	}
 
-	tx2, err := adapter.trader.SendTx(func() (client.Client, *types.Transaction, error) {
-		tx, err := adapter.renExBalancesContract.Deposit(adapter.trader.TransactOpts(), token.Addr, value)
+	tx2, err := adapter.trader.SendTx(step(ctx, journal.StepDeposit), func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		tx, err := adapter.renExBalancesContract.Deposit(opts, token.Addr, value)
 		return adapter.client, tx, err
 	})
-	if tx2 == nil {
-		return fmt.Errorf("Nil Approve Transaction")
-	}
 	if err != nil {
 		return err
 	}
+	if tx2 == nil {
+		return funds.ErrNilTransaction
+	}
 
-	if _, err := adapter.client.WaitTillMined(context.Background(), tx2); err != nil {
+	if _, err := adapter.client.WaitConfirmed(ctx, tx2, adapter.client.Confirmations()); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (adapter *adapter) CheckStatus(key *funds.IdempotentKey) uint8 {
+func (adapter *adapter) CheckStatus(ctx context.Context, key *funds.IdempotentKey) uint8 {
 	if key == nil {
 		return uint8(0)
 	}
 
-	tx, status, err := adapter.client.Client().TransactionByHash(context.Background(), common.Hash(*key))
+	tx, status, err := adapter.client.Client().TransactionByHash(ctx, common.Hash(*key))
 	if err != nil {
 		return uint8(7)
 	}
@@ -261,7 +262,7 @@ func (adapter *adapter) CheckStatus(key *funds.IdempotentKey) uint8 {
 		return uint8(7)
 	}
 
-	traderWithdrawalFailSafeTime, err := adapter.renExBalancesContract.TraderWithdrawalSignals(&bind.CallOpts{}, adapter.trader.Address(), common.BytesToAddress(txData[4:36]))
+	traderWithdrawalFailSafeTime, err := adapter.renExBalancesContract.TraderWithdrawalSignals(&bind.CallOpts{Context: ctx}, adapter.trader.Address(), common.BytesToAddress(txData[4:36]))
 	if err != nil {
 		return uint8(7)
 	}
@@ -273,29 +274,58 @@ func (adapter *adapter) CheckStatus(key *funds.IdempotentKey) uint8 {
 	return uint8(2)
 }
 
-func (adapter *adapter) RenExBalance(tokenCode order.Token) (*big.Int, error) {
-	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{}, uint32(tokenCode))
+func (adapter *adapter) RenExBalance(ctx context.Context, tokenCode order.Token) (*big.Int, error) {
+	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
 	if err != nil {
 		return nil, err
 	}
 
 	if !token.Registered {
-		return nil, fmt.Errorf("Unregistered token")
+		return nil, funds.ErrUnregisteredToken
 	}
 
-	return adapter.renExBalancesContract.TraderBalances(&bind.CallOpts{}, adapter.trader.Address(), token.Addr)
+	return adapter.renExBalancesContract.TraderBalances(&bind.CallOpts{Context: ctx}, adapter.trader.Address(), token.Addr)
 }
 
 func (adapter *adapter) Address() string {
 	return adapter.trader.Address().String()
 }
 
-func (adapter *adapter) TransferEth(address string, value *big.Int) error {
-	return adapter.client.Transfer(common.HexToAddress(address), adapter.trader.TransactOpts(), value)
+func (adapter *adapter) TransferEth(ctx context.Context, address string, value *big.Int) error {
+	return adapter.journaled(ctx, journal.KindTransfer, order.TokenETH, value, func(ctx context.Context) error {
+		return adapter.transferEth(step(ctx, journal.StepTransfer), address, value)
+	})
 }
 
-func (adapter *adapter) TransferERC20(address string, tokenCode order.Token, value *big.Int) error {
-	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{}, uint32(tokenCode))
+func (adapter *adapter) transferEth(ctx context.Context, address string, value *big.Int) error {
+	bound := bind.NewBoundContract(common.HexToAddress(address), abi.ABI{}, nil, adapter.client.Client(), nil)
+	tx, err := adapter.trader.SendTx(ctx, func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		opts.Value = value
+		// Gas cannot be estimated for transfers to accounts without code
+		if opts.GasLimit == 0 {
+			opts.GasLimit = 30000
+		}
+		tx, err := bound.Transfer(opts)
+		return adapter.client, tx, err
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := adapter.client.WaitTillMined(ctx, tx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (adapter *adapter) TransferERC20(ctx context.Context, address string, tokenCode order.Token, value *big.Int) error {
+	return adapter.journaled(ctx, journal.KindTransfer, tokenCode, value, func(ctx context.Context) error {
+		return adapter.transferERC20(step(ctx, journal.StepTransfer), address, tokenCode, value)
+	})
+}
+
+func (adapter *adapter) transferERC20(ctx context.Context, address string, tokenCode order.Token, value *big.Int) error {
+	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
 	if err != nil {
 		return err
 	}
@@ -305,27 +335,27 @@ func (adapter *adapter) TransferERC20(address string, tokenCode order.Token, val
 		return err
 	}
 
-	tx, err := adapter.trader.SendTx(func() (client.Client, *types.Transaction, error) {
-		tx, err := erc20.Transfer(adapter.trader.TransactOpts(), common.HexToAddress(address), value)
+	tx, err := adapter.trader.SendTx(ctx, func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		tx, err := erc20.Transfer(opts, common.HexToAddress(address), value)
 		return adapter.client, tx, err
 	})
 	if err != nil {
 		return err
 	}
 
-	if _, err := adapter.client.WaitTillMined(context.Background(), tx); err != nil {
+	if _, err := adapter.client.WaitTillMined(ctx, tx); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (adapter *adapter) BalanceEth() (*big.Int, error) {
-	return adapter.client.Client().BalanceAt(context.Background(), adapter.trader.Address(), nil)
+func (adapter *adapter) BalanceEth(ctx context.Context) (*big.Int, error) {
+	return adapter.client.Client().BalanceAt(ctx, adapter.trader.Address(), nil)
 }
 
-func (adapter *adapter) BalanceErc20(tokenCode order.Token) (*big.Int, error) {
-	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{}, uint32(tokenCode))
+func (adapter *adapter) BalanceErc20(ctx context.Context, tokenCode order.Token) (*big.Int, error) {
+	token, err := adapter.renExTokensContract.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
 	if err != nil {
 		return nil, err
 	}
@@ -335,7 +365,38 @@ func (adapter *adapter) BalanceErc20(tokenCode order.Token) (*big.Int, error) {
 		return nil, err
 	}
 
-	return erc20.BalanceOf(&bind.CallOpts{}, adapter.trader.Address())
+	return erc20.BalanceOf(&bind.CallOpts{Context: ctx}, adapter.trader.Address())
+}
+
+// journaled runs f as an operation recorded in the journal. If the context
+// already belongs to an operation, such as one being resumed, f is run as part
+// of that operation and the operation is left for the caller to finish.
+func (adapter *adapter) journaled(ctx context.Context, kind journal.Kind, tokenCode order.Token, value *big.Int, f func(context.Context) error) error {
+	if adapter.journal == nil {
+		return f(ctx)
+	}
+	if _, _, ok := journal.StepFromContext(ctx); ok {
+		return f(ctx)
+	}
+
+	id, err := adapter.journal.Begin(kind, tokenCode, value)
+	if err != nil {
+		return err
+	}
+	err = f(journal.WithStep(ctx, id, string(kind)))
+	if finishErr := adapter.journal.Finish(id, err); finishErr != nil && err == nil {
+		return finishErr
+	}
+	return err
+}
+
+// step returns a context that records transactions as a step of the
+// operation of ctx.
+func step(ctx context.Context, name string) context.Context {
+	if id, _, ok := journal.StepFromContext(ctx); ok {
+		return journal.WithStep(ctx, id, name)
+	}
+	return ctx
 }
 
 func toBytes32(b []byte) ([32]byte, error) {
@@ -0,0 +1,31 @@
+package ingress
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// IngressError is returned when the ingress responds with an unexpected
+// status code.
+type IngressError struct {
+	Status int
+	Body   string
+}
+
+func (err IngressError) Error() string {
+	return fmt.Sprintf("Unexpected status code %d: %s %s", err.Status, http.StatusText(err.Status), err.Body)
+}
+
+// CheckResponse reads the body of an ingress response. It returns an
+// IngressError if the response status is not 200 OK or 201 Created.
+func CheckResponse(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if !(resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK) {
+		return nil, IngressError{Status: resp.StatusCode, Body: string(body)}
+	}
+	return body, nil
+}
@@ -0,0 +1,244 @@
+package ingress
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/republicprotocol/renex-ingress-go/httpadapter"
+)
+
+// Config configures the timeouts and retries of a Client.
+type Config struct {
+	// Timeout bounds a single request to the ingress.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times a request is retried after a network
+	// error or a 5xx response. Zero uses the default, and NoRetries disables
+	// retries. POST requests are only retried when they were never sent, since
+	// the ingress may have acted on a request that failed afterwards.
+	MaxRetries int
+
+	// MinBackoff is the wait before the first retry. It doubles after every
+	// retry, up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// NoRetries is the MaxRetries of a Config that never retries a request.
+const NoRetries = -1
+
+// DefaultConfig is used for every zero field of a Config.
+var DefaultConfig = Config{
+	Timeout:    30 * time.Second,
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 8 * time.Second,
+}
+
+// SignatureResponse is returned by the ingress when it approves an order or a
+// withdrawal.
+type SignatureResponse struct {
+	Signature string `json:"signature"`
+}
+
+// AddressResponse is returned by the ingress for the atomic swap address of an
+// order.
+type AddressResponse struct {
+	Address string `json:"address"`
+}
+
+// AuthorizedResponse is returned by the ingress for the authorization of a
+// trader.
+type AuthorizedResponse struct {
+	Authorized bool `json:"authorized"`
+}
+
+// Client talks to the HTTP API of a RenEx ingress.
+type Client struct {
+	url        string
+	httpClient *http.Client
+	config     Config
+}
+
+// NewClient returns a Client for the ingress at a URL. A nil http.Client
+// uses http.DefaultClient.
+func NewClient(url string, httpClient *http.Client, config Config) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		url:        strings.TrimSuffix(url, "/"),
+		httpClient: httpClient,
+		config:     config.withDefaults(),
+	}
+}
+
+// URL returns the URL of the ingress.
+func (client *Client) URL() string {
+	return client.url
+}
+
+// OpenOrder sends the order fragments of an order to the ingress and returns
+// the signature with which the broker approves the order.
+func (client *Client) OpenOrder(ctx context.Context, req httpadapter.OpenOrderRequest) ([]byte, error) {
+	resp := SignatureResponse{}
+	if err := client.Post(ctx, "/orders", req, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Signature)
+}
+
+// ApproveWithdrawal returns the signature with which the broker approves a
+// withdrawal.
+func (client *Client) ApproveWithdrawal(ctx context.Context, req httpadapter.ApproveWithdrawalRequest) ([]byte, error) {
+	resp := SignatureResponse{}
+	if err := client.Post(ctx, "/withdrawals", req, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Signature)
+}
+
+// Health returns nil if the ingress is up.
+func (client *Client) Health(ctx context.Context) error {
+	return client.Get(ctx, "/health", nil)
+}
+
+// SwapAddress returns the address that the trader of an order registered to
+// receive its atomic swap.
+func (client *Client) SwapAddress(ctx context.Context, orderID [32]byte) (string, error) {
+	resp := AddressResponse{}
+	if err := client.Get(ctx, "/address/"+base64.URLEncoding.EncodeToString(orderID[:]), &resp); err != nil {
+		return "", err
+	}
+	return resp.Address, nil
+}
+
+// Authorized returns whether a trader is authorized to open orders through the
+// ingress.
+func (client *Client) Authorized(ctx context.Context, trader string) (bool, error) {
+	resp := AuthorizedResponse{}
+	if err := client.Get(ctx, "/authorized/"+trader, &resp); err != nil {
+		return false, err
+	}
+	return resp.Authorized, nil
+}
+
+// Get sends a GET request to a path of the ingress and decodes the JSON
+// response into v.
+func (client *Client) Get(ctx context.Context, path string, v interface{}) error {
+	return client.do(ctx, http.MethodGet, path, nil, v)
+}
+
+// Post sends req as JSON to a path of the ingress and decodes the JSON
+// response into v.
+func (client *Client) Post(ctx context.Context, path string, req, v interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return client.do(ctx, http.MethodPost, path, data, v)
+}
+
+// do sends a request, retrying with exponential backoff when the request
+// fails or the ingress responds with a 5xx status. A POST request is only
+// retried if it could not be sent.
+func (client *Client) do(ctx context.Context, method, path string, data []byte, v interface{}) error {
+	backoff := client.config.MinBackoff
+	for attempt := 0; ; attempt++ {
+		body, err := client.send(ctx, method, path, data)
+		if err == nil {
+			if v == nil || len(body) == 0 {
+				return nil
+			}
+			return json.Unmarshal(body, v)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryable(method, err) || attempt >= client.config.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > client.config.MaxBackoff {
+			backoff = client.config.MaxBackoff
+		}
+	}
+}
+
+func (client *Client) send(ctx context.Context, method, path string, data []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.config.Timeout)
+	defer cancel()
+
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", client.url, path), body)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return CheckResponse(resp)
+}
+
+// retryable returns true for network errors and 5xx responses. Other
+// responses from the ingress will not change when the request is repeated.
+// POST requests are not idempotent, so they are only retried when the
+// connection to the ingress could not be made.
+func retryable(method string, err error) bool {
+	if method == http.MethodPost {
+		return notSent(err)
+	}
+	if err, ok := err.(IngressError); ok {
+		return err.Status >= 500
+	}
+	return true
+}
+
+// notSent returns true if the error shows that a request never reached the
+// ingress.
+func notSent(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+func (config Config) withDefaults() Config {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultConfig.Timeout
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultConfig.MaxRetries
+	}
+	if config.MinBackoff == 0 {
+		config.MinBackoff = DefaultConfig.MinBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultConfig.MaxBackoff
+	}
+	return config
+}
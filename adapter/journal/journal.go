@@ -0,0 +1,282 @@
+package journal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/republicprotocol/renex-sdk-go/adapter/store"
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// Kind is the kind of fund operation that sent a transaction.
+type Kind string
+
+// Values for Kind.
+const (
+	KindDeposit     = Kind("deposit")
+	KindWithdraw    = Kind("withdraw")
+	KindTransfer    = Kind("transfer")
+	KindTransaction = Kind("transaction")
+)
+
+// Steps of the operations sent by the funds adapter.
+const (
+	StepApprove  = "approve"
+	StepDeposit  = "deposit"
+	StepWithdraw = "withdraw"
+	StepSignal   = "signal"
+	StepTransfer = "transfer"
+)
+
+// State is the state of an operation or of one of its transactions.
+type State string
+
+// Values for State. A transaction is Signed when it is written to the journal
+// before it is broadcast, Sent once a node accepts it and Mined once it is
+// mined. An operation is Pending until it is Done or Failed.
+const (
+	StatePending = State("pending")
+	StateSigned  = State("signed")
+	StateSent    = State("sent")
+	StateMined   = State("mined")
+	StateDone    = State("done")
+	StateFailed  = State("failed")
+)
+
+// Entry records a transaction sent as a step of an operation. A transaction
+// that replaces another one is recorded in the same step, and Cancel is set
+// when it cancels the step.
+type Entry struct {
+	Step    string      `json:"step"`
+	Hash    common.Hash `json:"hash"`
+	Nonce   uint64      `json:"nonce"`
+	State   State       `json:"state"`
+	Cancel  bool        `json:"cancel,omitempty"`
+	Updated time.Time   `json:"updated"`
+}
+
+// Operation records a fund operation and the transactions it has sent.
+type Operation struct {
+	ID      string      `json:"id"`
+	Kind    Kind        `json:"kind"`
+	Token   order.Token `json:"token"`
+	Amount  *big.Int    `json:"amount"`
+	State   State       `json:"state"`
+	Error   string      `json:"error,omitempty"`
+	Entries []Entry     `json:"entries"`
+	Created time.Time   `json:"created"`
+}
+
+// Entry returns the last entry of a step, and false if the step has no
+// entries.
+func (op Operation) Entry(step string) (Entry, bool) {
+	for i := len(op.Entries) - 1; i >= 0; i-- {
+		if op.Entries[i].Step == step {
+			return op.Entries[i], true
+		}
+	}
+	return Entry{}, false
+}
+
+// Journal records fund operations in a store, so that operations interrupted
+// by a crash can be completed when the SDK restarts.
+type Journal interface {
+	// Begin records a new pending operation and returns its ID.
+	Begin(kind Kind, token order.Token, amount *big.Int) (string, error)
+
+	// Record adds an entry to an operation, replacing any entry with the same
+	// hash.
+	Record(id string, entry Entry) error
+
+	// Finish marks an operation as done, or as failed if err is not nil.
+	Finish(id string, err error) error
+
+	// Pending returns the operations that have not finished.
+	Pending() ([]Operation, error)
+
+	// Operation returns an operation.
+	Operation(id string) (Operation, error)
+}
+
+type journal struct {
+	store store.StoreAdapter
+	mu    *sync.Mutex
+}
+
+// NewJournal returns a Journal that keeps its operations in a store.
+func NewJournal(storeAdapter store.StoreAdapter) Journal {
+	return &journal{
+		store: storeAdapter,
+		mu:    new(sync.Mutex),
+	}
+}
+
+func (journal *journal) Begin(kind Kind, token order.Token, amount *big.Int) (string, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	op := Operation{
+		ID:      hex.EncodeToString(id),
+		Kind:    kind,
+		Token:   token,
+		Amount:  amount,
+		State:   StatePending,
+		Entries: []Entry{},
+		Created: time.Now(),
+	}
+	if err := journal.write(op); err != nil {
+		return "", err
+	}
+
+	ids, err := journal.pendingIDs()
+	if err != nil {
+		return "", err
+	}
+	return op.ID, journal.writePendingIDs(append(ids, op.ID))
+}
+
+func (journal *journal) Record(id string, entry Entry) error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	op, err := journal.read(id)
+	if err != nil {
+		return err
+	}
+	entry.Updated = time.Now()
+	for i := range op.Entries {
+		if op.Entries[i].Hash == entry.Hash {
+			op.Entries[i] = entry
+			return journal.write(op)
+		}
+	}
+	op.Entries = append(op.Entries, entry)
+	return journal.write(op)
+}
+
+func (journal *journal) Finish(id string, err error) error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	op, readErr := journal.read(id)
+	if readErr != nil {
+		return readErr
+	}
+	op.State = StateDone
+	if err != nil {
+		op.State = StateFailed
+		op.Error = err.Error()
+	}
+	if err := journal.write(op); err != nil {
+		return err
+	}
+
+	ids, err := journal.pendingIDs()
+	if err != nil {
+		return err
+	}
+	for i := range ids {
+		if ids[i] == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	return journal.writePendingIDs(ids)
+}
+
+func (journal *journal) Pending() ([]Operation, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	ids, err := journal.pendingIDs()
+	if err != nil {
+		return nil, err
+	}
+	ops := make([]Operation, 0, len(ids))
+	for _, id := range ids {
+		op, err := journal.read(id)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func (journal *journal) Operation(id string) (Operation, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	return journal.read(id)
+}
+
+func (journal *journal) read(id string) (Operation, error) {
+	data, err := journal.store.Read(append([]byte("JOURNAL"), id...))
+	if err != nil {
+		return Operation{}, err
+	}
+	op := Operation{}
+	if err := json.Unmarshal(data, &op); err != nil {
+		return Operation{}, err
+	}
+	return op, nil
+}
+
+func (journal *journal) write(op Operation) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	return journal.store.Write(append([]byte("JOURNAL"), op.ID...), data)
+}
+
+func (journal *journal) pendingIDs() ([]string, error) {
+	data, err := journal.store.Read([]byte("JOURNALS"))
+	if err == store.ErrOrdersNotFound {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (journal *journal) writePendingIDs(ids []string) error {
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return journal.store.Write([]byte("JOURNALS"), data)
+}
+
+type stepKey struct{}
+
+type step struct {
+	id   string
+	name string
+}
+
+// WithStep returns a context that records the transactions sent with it as a
+// step of an operation.
+func WithStep(ctx context.Context, id, name string) context.Context {
+	return context.WithValue(ctx, stepKey{}, step{id, name})
+}
+
+// StepFromContext returns the operation and step recorded by WithStep.
+func StepFromContext(ctx context.Context) (string, string, bool) {
+	s, ok := ctx.Value(stepKey{}).(step)
+	return s.id, s.name, ok
+}
@@ -0,0 +1,92 @@
+package leveldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/republicprotocol/republic-go/order"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// EnvRoot is the environment variable that overrides the directory in which
+// stores are kept.
+const EnvRoot = "RENEX_HOME"
+
+var legacyName = regexp.MustCompile("^db[0-9a-f]{64}$")
+
+// DefaultRoot returns the directory in which stores are kept, which is
+// $RENEX_HOME if it is set and $HOME/.renex otherwise.
+func DefaultRoot() string {
+	if root := os.Getenv(EnvRoot); root != "" {
+		return root
+	}
+	return filepath.Join(os.Getenv("HOME"), ".renex")
+}
+
+// Path returns the location of the store of a trader on a network, so that the
+// same store is reopened every time the trader connects to the network.
+func Path(root, network string, trader common.Address) string {
+	return filepath.Join(root, network, strings.ToLower(trader.Hex()))
+}
+
+// IndexPath returns the location of the store of the orderbook index that is
+// shared by the accounts of a pool on a network. It cannot be the store of a
+// trader, since those are named after addresses.
+func IndexPath(root, network string) string {
+	return filepath.Join(root, network, "index")
+}
+
+// LegacyStores returns the paths of the randomly named stores that were
+// created in the root directory by earlier versions of the SDK.
+func LegacyStores(root string) ([]string, error) {
+	infos, err := ioutil.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	paths := []string{}
+	for _, info := range infos {
+		if info.IsDir() && legacyName.MatchString(info.Name()) {
+			paths = append(paths, filepath.Join(root, info.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// LegacyOrders reads every order from a legacy store. Earlier versions of the
+// SDK did not persist the list of orders, so the orders are found by scanning
+// the order keys.
+func LegacyOrders(path string) ([]order.Order, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	prefix := []byte("ORDER")
+	iter := db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	ords := []order.Order{}
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+32 || !bytes.HasPrefix(key, prefix) {
+			continue
+		}
+		ord := order.Order{}
+		if err := json.Unmarshal(iter.Value(), &ord); err != nil {
+			return nil, err
+		}
+		ords = append(ords, ord)
+	}
+	return ords, iter.Error()
+}
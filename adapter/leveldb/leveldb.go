@@ -3,6 +3,7 @@ package leveldb
 import (
 	"github.com/republicprotocol/renex-sdk-go/adapter/store"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 )
 
 type ldbStore struct {
@@ -26,6 +27,18 @@ func NewLDBStore(path string) (Store, error) {
 	}, nil
 }
 
+// NewMemLDBStore returns a store that is kept in memory and discarded when it
+// is closed.
+func NewMemLDBStore() (Store, error) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ldbStore{
+		db: db,
+	}, nil
+}
+
 func (ldb *ldbStore) Read(key []byte) ([]byte, error) {
 	value, err := ldb.db.Get(key, nil)
 	if err == leveldb.ErrNotFound {
@@ -0,0 +1,172 @@
+package local
+
+import (
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/republicprotocol/renex-ingress-go/httpadapter"
+	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
+	"github.com/republicprotocol/renex-sdk-go/adapter/trader"
+)
+
+// Ingress is an in-process stand-in for the RenEx ingress. It approves every
+// order and withdrawal by signing them with the broker key that is registered
+// on the local chain.
+type Ingress struct {
+	URL string
+
+	broker         *ecdsa.PrivateKey
+	brokerVerifier *bindings.RenExBrokerVerifier
+	listener       net.Listener
+	server         *http.Server
+
+	serveMu  *sync.Mutex
+	serveErr error
+	served   chan struct{}
+}
+
+// NewIngress starts an ingress for the chain on a random localhost port. The
+// ingress is closed with the chain.
+func NewIngress(chain *Chain) (*Ingress, error) {
+	backend := bind.ContractBackend(chain.Client.Client())
+	balances, err := bindings.NewRenExBalances(chain.Client.RenExBalancesAddress(), backend)
+	if err != nil {
+		return nil, err
+	}
+	brokerVerifierAddr, err := balances.BrokerVerifierContract(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
+	brokerVerifier, err := bindings.NewRenExBrokerVerifier(brokerVerifierAddr, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	ingress := &Ingress{
+		URL:            fmt.Sprintf("http://%s", listener.Addr().String()),
+		broker:         chain.Broker,
+		brokerVerifier: brokerVerifier,
+		listener:       listener,
+		serveMu:        new(sync.Mutex),
+		served:         make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders", ingress.openOrder)
+	mux.HandleFunc("/withdrawals", ingress.approveWithdrawal)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	ingress.server = &http.Server{Handler: mux}
+	go func() {
+		defer close(ingress.served)
+		if err := ingress.server.Serve(listener); err != http.ErrServerClosed {
+			ingress.serveMu.Lock()
+			ingress.serveErr = err
+			ingress.serveMu.Unlock()
+		}
+	}()
+
+	chain.closeWith(ingress)
+	return ingress, nil
+}
+
+// Err returns the error that stopped the ingress from serving requests, or
+// nil if it is still serving or was closed.
+func (ingress *Ingress) Err() error {
+	ingress.serveMu.Lock()
+	defer ingress.serveMu.Unlock()
+	return ingress.serveErr
+}
+
+// Close stops the ingress. It returns the error that stopped the ingress if it
+// had already stopped serving requests.
+func (ingress *Ingress) Close() error {
+	if err := ingress.server.Close(); err != nil {
+		return err
+	}
+	<-ingress.served
+	return ingress.Err()
+}
+
+func (ingress *Ingress) openOrder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req := httpadapter.OpenOrderRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var orderID []byte
+	for _, mapping := range req.OrderFragmentMappings {
+		for _, fragments := range mapping {
+			for _, fragment := range fragments {
+				id, err := base64.StdEncoding.DecodeString(fragment.OrderID)
+				if err != nil || len(id) != 32 {
+					http.Error(w, "malformed order id", http.StatusBadRequest)
+					return
+				}
+				orderID = id
+			}
+		}
+	}
+	if orderID == nil {
+		http.Error(w, "missing order id", http.StatusBadRequest)
+		return
+	}
+
+	id := [32]byte{}
+	copy(id[:], orderID)
+	ingress.respond(w, trader.OpenMessage(common.HexToAddress(req.Address), id))
+}
+
+func (ingress *Ingress) approveWithdrawal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req := httpadapter.ApproveWithdrawalRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	address := common.HexToAddress(req.Trader)
+	nonce, err := ingress.brokerVerifier.TraderNonces(&bind.CallOpts{}, address)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ingress.respond(w, trader.WithdrawMessage(address, nonce))
+}
+
+// respond signs the message in the same way as the RenEx broker, and writes
+// the signature to the response.
+func (ingress *Ingress) respond(w http.ResponseWriter, message []byte) {
+	signature, err := crypto.Sign(trader.PersonalHash(message), ingress.broker)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		Signature string `json:"signature"`
+	}{base64.StdEncoding.EncodeToString(signature)})
+}
@@ -0,0 +1,236 @@
+package local
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// Version is the VERSION given to every contract deployed to the local chain.
+const Version = "local-1.0.0"
+
+// Token describes an ERC20 token deployed to the local chain.
+type Token struct {
+	Code     order.Token
+	Decimals uint8
+}
+
+// Tokens are the ERC20 tokens deployed and registered on the local chain. ETH
+// is registered as well, using the RenExBalances ETHEREUM address.
+var Tokens = []Token{
+	{order.TokenDGX, 9},
+	{order.TokenTUSD, 18},
+	{order.TokenREN, 18},
+	{order.TokenZRX, 18},
+	{order.TokenOMG, 18},
+}
+
+var (
+	accountEther  = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	accountTokens = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(1e18))
+	minimumBond   = big.NewInt(1e18)
+	gasPriceLimit = big.NewInt(100e9)
+)
+
+// Chain is a set of RenEx contracts deployed to an in-process simulated
+// Ethereum chain.
+type Chain struct {
+	Network client.Network
+	Client  client.Client
+	Owner   *bind.TransactOpts
+	Broker  *ecdsa.PrivateKey
+	Tokens  map[order.Token]common.Address
+
+	closersMu *sync.Mutex
+	closers   []io.Closer
+}
+
+// NewChain starts a simulated chain, deploys and wires the RenEx contracts and
+// funds every account with ether and every registered ERC20 token.
+func NewChain(accounts ...common.Address) (*Chain, error) {
+	ownerKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	brokerKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	owner := bind.NewKeyedTransactor(ownerKey)
+
+	alloc := core.GenesisAlloc{owner.From: {Balance: new(big.Int).Mul(accountEther, big.NewInt(int64(len(accounts)+1)))}}
+	for _, account := range accounts {
+		alloc[account] = core.GenesisAccount{Balance: accountEther}
+	}
+	backend := client.NewSimulatedBackend(backends.NewSimulatedBackend(alloc))
+	chain := &chainBuilder{
+		backend: backend,
+		owner:   owner,
+		client:  client.NewSimulatedClient(backend, client.Network{}),
+	}
+
+	network, tokens, err := chain.deploy(crypto.PubkeyToAddress(brokerKey.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	if err := chain.fund(tokens, accounts); err != nil {
+		return nil, err
+	}
+
+	return &Chain{
+		Network: network,
+		Client:  client.NewSimulatedClient(backend, network),
+		Owner:   owner,
+		Broker:  brokerKey,
+		Tokens:  tokens,
+
+		closersMu: new(sync.Mutex),
+		closers:   []io.Closer{},
+	}, nil
+}
+
+// Close stops every ingress started for the chain. It returns the first error
+// encountered, but always closes every ingress.
+func (chain *Chain) Close() error {
+	chain.closersMu.Lock()
+	closers := chain.closers
+	chain.closers = []io.Closer{}
+	chain.closersMu.Unlock()
+
+	var err error
+	for _, closer := range closers {
+		if closeErr := closer.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (chain *Chain) closeWith(closer io.Closer) {
+	chain.closersMu.Lock()
+	defer chain.closersMu.Unlock()
+	chain.closers = append(chain.closers, closer)
+}
+
+type chainBuilder struct {
+	backend client.Backend
+	owner   *bind.TransactOpts
+	client  client.Client
+	err     error
+}
+
+// mined waits for a transaction and records the first error encountered so
+// that a sequence of deployments can be checked once at the end.
+func (chain *chainBuilder) mined(tx *types.Transaction, err error) {
+	if chain.err != nil {
+		return
+	}
+	if err != nil {
+		chain.err = err
+		return
+	}
+	_, chain.err = chain.client.WaitTillMined(context.Background(), tx)
+}
+
+func (chain *chainBuilder) deploy(broker common.Address) (client.Network, map[order.Token]common.Address, error) {
+	backend := bind.ContractBackend(chain.backend)
+
+	renAddr, tx, _, err := bindings.DeployRepublicToken(chain.owner, backend)
+	chain.mined(tx, err)
+	dnrStoreAddr, tx, dnrStore, err := bindings.DeployDarknodeRegistryStore(chain.owner, backend, Version, renAddr)
+	chain.mined(tx, err)
+	dnrAddr, tx, _, err := bindings.DeployDarknodeRegistry(chain.owner, backend, Version, renAddr, dnrStoreAddr, minimumBond, big.NewInt(1), big.NewInt(1))
+	chain.mined(tx, err)
+	if chain.err == nil {
+		chain.mined(dnrStore.TransferOwnership(chain.owner, dnrAddr))
+	}
+	settlementRegistryAddr, tx, settlementRegistry, err := bindings.DeploySettlementRegistry(chain.owner, backend, Version)
+	chain.mined(tx, err)
+	orderbookAddr, tx, _, err := bindings.DeployOrderbook(chain.owner, backend, Version, renAddr, dnrAddr, settlementRegistryAddr)
+	chain.mined(tx, err)
+	rewardVaultAddr, tx, _, err := bindings.DeployDarknodeRewardVault(chain.owner, backend, Version, dnrAddr)
+	chain.mined(tx, err)
+	brokerVerifierAddr, tx, brokerVerifier, err := bindings.DeployRenExBrokerVerifier(chain.owner, backend, Version)
+	chain.mined(tx, err)
+	balancesAddr, tx, balances, err := bindings.DeployRenExBalances(chain.owner, backend, Version, rewardVaultAddr, brokerVerifierAddr)
+	chain.mined(tx, err)
+	tokensAddr, tx, renExTokens, err := bindings.DeployRenExTokens(chain.owner, backend, Version)
+	chain.mined(tx, err)
+	settlementAddr, tx, settlement, err := bindings.DeployRenExSettlement(chain.owner, backend, Version, orderbookAddr, tokensAddr, balancesAddr, chain.owner.From, gasPriceLimit)
+	chain.mined(tx, err)
+	if chain.err != nil {
+		return client.Network{}, nil, chain.err
+	}
+
+	settlementID, err := settlement.RENEXSETTLEMENTID(&bind.CallOpts{})
+	if err != nil {
+		return client.Network{}, nil, err
+	}
+	chain.mined(balances.UpdateRenExSettlementContract(chain.owner, settlementAddr))
+	chain.mined(brokerVerifier.UpdateBalancesContract(chain.owner, balancesAddr))
+	chain.mined(brokerVerifier.RegisterBroker(chain.owner, broker))
+	chain.mined(settlementRegistry.RegisterSettlement(chain.owner, uint64(settlementID), settlementAddr, brokerVerifierAddr))
+	if chain.err != nil {
+		return client.Network{}, nil, chain.err
+	}
+
+	ethAddr, err := balances.ETHEREUM(&bind.CallOpts{})
+	if err != nil {
+		return client.Network{}, nil, err
+	}
+	tokens := map[order.Token]common.Address{order.TokenETH: ethAddr}
+	chain.mined(renExTokens.RegisterToken(chain.owner, uint32(order.TokenETH), ethAddr, 18))
+	for _, token := range Tokens {
+		addr := renAddr
+		if token.Code != order.TokenREN {
+			addr, tx, _, err = bindings.DeployRepublicToken(chain.owner, backend)
+			chain.mined(tx, err)
+		}
+		chain.mined(renExTokens.RegisterToken(chain.owner, uint32(token.Code), addr, token.Decimals))
+		tokens[token.Code] = addr
+	}
+	if chain.err != nil {
+		return client.Network{}, nil, chain.err
+	}
+
+	return client.Network{
+		Name:                    client.NetworkLocal,
+		Chain:                   client.NetworkLocal,
+		DarknodeRegistryAddress: dnrAddr.String(),
+		OrderbookAddress:        orderbookAddr.String(),
+		RenExBalancesAddress:    balancesAddr.String(),
+		RenExTokensAddress:      tokensAddr.String(),
+		RenExSettlementAddress:  settlementAddr.String(),
+
+		SettlementRegistryAddress:  settlementRegistryAddr.String(),
+		RenExBrokerVerifierAddress: brokerVerifierAddr.String(),
+	}, tokens, nil
+}
+
+func (chain *chainBuilder) fund(tokens map[order.Token]common.Address, accounts []common.Address) error {
+	for code, addr := range tokens {
+		if code == order.TokenETH {
+			continue
+		}
+		token, err := bindings.NewRepublicToken(addr, bind.ContractBackend(chain.backend))
+		if err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			chain.mined(token.Transfer(chain.owner, account, accountTokens))
+		}
+	}
+	return chain.err
+}
@@ -0,0 +1,425 @@
+// Package offline prepares fund transactions on a machine that is connected
+// to Ethereum, so that they can be signed on a machine that is not and
+// broadcast later.
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/trader"
+	"github.com/republicprotocol/renex-sdk-go/core/funds"
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// BundleVersion is the version of the bundle format written by this package.
+const BundleVersion = 1
+
+// DependentGasLimit is the gas limit of a transaction that cannot be
+// estimated because it depends on an earlier transaction of its bundle, such
+// as a deposit that spends an approval.
+const DependentGasLimit = 250000
+
+// ErrNotSigned is returned when broadcasting a bundle that has unsigned
+// transactions.
+var ErrNotSigned = errors.New("Bundle is not signed")
+
+// ErrWrongSigner is returned when a bundle is signed with a key that is not
+// the key of the account that the bundle was prepared for.
+var ErrWrongSigner = errors.New("Bundle is for a different account")
+
+// ErrWrongChain is returned when a bundle is broadcast to a different chain
+// than the chain it was prepared for.
+var ErrWrongChain = errors.New("Bundle is for a different chain")
+
+// Tx is a transaction of a bundle. The fields that describe the transaction
+// are set when the bundle is prepared, and Signed and Hash are set when it is
+// signed.
+type Tx struct {
+	Description string         `json:"description"`
+	To          common.Address `json:"to"`
+	Value       *big.Int       `json:"value"`
+	Data        hexutil.Bytes  `json:"data"`
+	Nonce       uint64         `json:"nonce"`
+	Gas         uint64         `json:"gas"`
+	GasPrice    *big.Int       `json:"gasPrice"`
+	Signed      hexutil.Bytes  `json:"signed,omitempty"`
+	Hash        *common.Hash   `json:"hash,omitempty"`
+}
+
+// Transaction returns the unsigned transaction.
+func (tx Tx) Transaction() *types.Transaction {
+	return types.NewTransaction(tx.Nonce, tx.To, tx.Value, tx.Gas, tx.GasPrice, tx.Data)
+}
+
+// Bundle is a sequence of transactions that make up a fund operation. The
+// transactions must be mined in order. Bundles are written as indented JSON
+// so that they can be reviewed before they are signed. Network is the name of
+// the network that the bundle was prepared on, and ChainID is zero when the
+// transactions are signed without replay protection.
+type Bundle struct {
+	Version      int            `json:"version"`
+	Network      string         `json:"network"`
+	ChainID      uint64         `json:"chainId"`
+	From         common.Address `json:"from"`
+	Operation    string         `json:"operation"`
+	Token        order.Token    `json:"token"`
+	Amount       *big.Int       `json:"amount"`
+	Created      time.Time      `json:"created"`
+	Transactions []Tx           `json:"transactions"`
+}
+
+// Signed returns true if every transaction of the bundle is signed.
+func (bundle Bundle) Signed() bool {
+	for _, tx := range bundle.Transactions {
+		if len(tx.Signed) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteBundle writes a bundle as indented JSON.
+func WriteBundle(w io.Writer, bundle Bundle) error {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadBundle reads a bundle written by WriteBundle.
+func ReadBundle(r io.Reader) (Bundle, error) {
+	bundle := Bundle{}
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return Bundle{}, err
+	}
+	if bundle.Version != BundleVersion {
+		return Bundle{}, fmt.Errorf("cannot read bundle: unsupported version %d", bundle.Version)
+	}
+	return bundle, nil
+}
+
+// Builder prepares bundles for an account using the same contract calls as
+// the funds adapter.
+type Builder struct {
+	client        client.Client
+	from          common.Address
+	gasStrategy   trader.GasStrategy
+	renExBalances *bindings.RenExBalances
+	renExTokens   *bindings.RenExTokens
+}
+
+// NewBuilder returns a Builder for the account at an address. The gas price of
+// the transactions is chosen by the GasStrategy, and defaults to the gas
+// price suggested by the node when it is nil.
+func NewBuilder(c client.Client, from common.Address, gasStrategy trader.GasStrategy) (*Builder, error) {
+	renExBalances, err := bindings.NewRenExBalances(c.RenExBalancesAddress(), bind.ContractBackend(c.Client()))
+	if err != nil {
+		return nil, err
+	}
+	renExTokens, err := bindings.NewRenExTokens(c.RenExTokensAddress(), bind.ContractBackend(c.Client()))
+	if err != nil {
+		return nil, err
+	}
+	if gasStrategy == nil {
+		gasStrategy = trader.NewSuggestedGasStrategy()
+	}
+	return &Builder{
+		client:        c,
+		from:          from,
+		gasStrategy:   gasStrategy,
+		renExBalances: renExBalances,
+		renExTokens:   renExTokens,
+	}, nil
+}
+
+// Deposit prepares the deposit of a token into RenEx. ERC20 deposits approve
+// the RenExBalances contract first, unless it is already allowed to spend
+// the amount.
+func (builder *Builder) Deposit(ctx context.Context, tokenCode order.Token, value *big.Int) (Bundle, error) {
+	token, err := builder.token(ctx, tokenCode)
+	if err != nil {
+		return Bundle{}, err
+	}
+	eth, err := builder.renExBalances.ETHEREUM(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	bundle := builder.newBundle("deposit", tokenCode, value)
+	if eth == token {
+		if err := builder.add(ctx, &bundle, fmt.Sprintf("Deposit %v wei into RenExBalances", value), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = value
+			return builder.renExBalances.Deposit(opts, token, value)
+		}); err != nil {
+			return Bundle{}, err
+		}
+		return bundle, nil
+	}
+
+	erc20, err := bindings.NewERC20(token, bind.ContractBackend(builder.client.Client()))
+	if err != nil {
+		return Bundle{}, err
+	}
+	allowance, err := erc20.Allowance(&bind.CallOpts{Context: ctx}, builder.from, builder.client.RenExBalancesAddress())
+	if err != nil {
+		return Bundle{}, err
+	}
+	if allowance.Cmp(value) < 0 {
+		if err := builder.add(ctx, &bundle, fmt.Sprintf("Approve RenExBalances to spend %v of token %v", value, tokenCode), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return erc20.Approve(opts, builder.client.RenExBalancesAddress(), value)
+		}); err != nil {
+			return Bundle{}, err
+		}
+	}
+	if err := builder.add(ctx, &bundle, fmt.Sprintf("Deposit %v of token %v into RenExBalances", value, tokenCode), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return builder.renExBalances.Deposit(opts, token, value)
+	}); err != nil {
+		return Bundle{}, err
+	}
+	return bundle, nil
+}
+
+// Withdraw prepares the withdrawal of a token from RenEx. The signature is
+// the approval of the ingress, or empty for a fail safe withdrawal that has
+// been signalled. The approval covers the withdrawal nonce of the trader, so
+// the bundle must be broadcast before any other withdrawal of the trader is
+// mined, otherwise it is reverted and must be prepared again.
+func (builder *Builder) Withdraw(ctx context.Context, tokenCode order.Token, value *big.Int, signature []byte) (Bundle, error) {
+	token, err := builder.token(ctx, tokenCode)
+	if err != nil {
+		return Bundle{}, err
+	}
+	bundle := builder.newBundle("withdraw", tokenCode, value)
+	if err := builder.add(ctx, &bundle, fmt.Sprintf("Withdraw %v of token %v from RenExBalances", value, tokenCode), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return builder.renExBalances.Withdraw(opts, token, value, signature)
+	}); err != nil {
+		return Bundle{}, err
+	}
+	return bundle, nil
+}
+
+// Transfer prepares the transfer of ether or an ERC20 token to an address.
+func (builder *Builder) Transfer(ctx context.Context, to common.Address, tokenCode order.Token, value *big.Int) (Bundle, error) {
+	bundle := builder.newBundle("transfer", tokenCode, value)
+	if tokenCode == order.TokenETH {
+		bound := bind.NewBoundContract(to, abi.ABI{}, nil, builder.client.Client(), nil)
+		if err := builder.add(ctx, &bundle, fmt.Sprintf("Transfer %v wei to %s", value, to.Hex()), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = value
+			return bound.Transfer(opts)
+		}); err != nil {
+			return Bundle{}, err
+		}
+		return bundle, nil
+	}
+
+	token, err := builder.token(ctx, tokenCode)
+	if err != nil {
+		return Bundle{}, err
+	}
+	erc20, err := bindings.NewERC20(token, bind.ContractBackend(builder.client.Client()))
+	if err != nil {
+		return Bundle{}, err
+	}
+	if err := builder.add(ctx, &bundle, fmt.Sprintf("Transfer %v of token %v to %s", value, tokenCode, to.Hex()), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return erc20.Transfer(opts, to, value)
+	}); err != nil {
+		return Bundle{}, err
+	}
+	return bundle, nil
+}
+
+func (builder *Builder) token(ctx context.Context, tokenCode order.Token) (common.Address, error) {
+	token, err := builder.renExTokens.Tokens(&bind.CallOpts{Context: ctx}, uint32(tokenCode))
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !token.Registered {
+		return common.Address{}, funds.ErrUnregisteredToken
+	}
+	return token.Addr, nil
+}
+
+func (builder *Builder) newBundle(operation string, tokenCode order.Token, value *big.Int) Bundle {
+	bundle := Bundle{
+		Version:      BundleVersion,
+		Network:      builder.client.Network(),
+		From:         builder.from,
+		Operation:    operation,
+		Token:        tokenCode,
+		Amount:       value,
+		Created:      time.Now().UTC(),
+		Transactions: []Tx{},
+	}
+	if chainID := builder.client.ChainID(); chainID != nil {
+		bundle.ChainID = chainID.Uint64()
+	}
+	return bundle
+}
+
+// add creates a transaction with f and appends it to the bundle. The nonce
+// follows the last transaction of the bundle, and the gas limit is estimated
+// unless the transaction depends on an earlier one.
+func (builder *Builder) add(ctx context.Context, bundle *Bundle, description string, f func(*bind.TransactOpts) (*types.Transaction, error)) error {
+	nonce, err := builder.client.Client().PendingNonceAt(ctx, builder.from)
+	if err != nil {
+		return err
+	}
+	if n := len(bundle.Transactions); n > 0 {
+		nonce = bundle.Transactions[n-1].Nonce + 1
+	}
+	gasPrice, err := builder.gasStrategy.GasPrice(ctx, builder.client.Client())
+	if err != nil {
+		return err
+	}
+
+	// Capture the transaction without signing it. The gas limit is set so
+	// that bind does not estimate it.
+	var unsigned *types.Transaction
+	errCaptured := errors.New("captured")
+	opts := &bind.TransactOpts{
+		From:     builder.from,
+		Nonce:    new(big.Int).SetUint64(nonce),
+		GasPrice: gasPrice,
+		GasLimit: DependentGasLimit,
+		Context:  ctx,
+		Signer: func(_ types.Signer, _ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			unsigned = tx
+			return nil, errCaptured
+		},
+	}
+	if _, err := f(opts); err != errCaptured {
+		if err == nil {
+			err = errors.New("transaction was not captured")
+		}
+		return fmt.Errorf("cannot prepare transaction: %v", err)
+	}
+
+	gas := uint64(DependentGasLimit)
+	estimated, err := builder.client.Client().EstimateGas(ctx, ethereum.CallMsg{
+		From:  builder.from,
+		To:    unsigned.To(),
+		Value: unsigned.Value(),
+		Data:  unsigned.Data(),
+	})
+	switch {
+	case err == nil:
+		gas = estimated + estimated*trader.DefaultGasLimitMargin/100
+	case len(bundle.Transactions) == 0:
+		return fmt.Errorf("cannot estimate gas: %v", err)
+	}
+
+	bundle.Transactions = append(bundle.Transactions, Tx{
+		Description: description,
+		To:          *unsigned.To(),
+		Value:       unsigned.Value(),
+		Data:        unsigned.Data(),
+		Nonce:       nonce,
+		Gas:         gas,
+		GasPrice:    gasPrice,
+	})
+	return nil
+}
+
+// Sign signs every transaction of a bundle with a Signer, which must be the
+// signer of the account that the bundle was prepared for.
+func Sign(bundle Bundle, signer trader.Signer) (Bundle, error) {
+	if signer.Address() != bundle.From {
+		return Bundle{}, ErrWrongSigner
+	}
+
+	signed := bundle
+	signed.Transactions = make([]Tx, len(bundle.Transactions))
+	for i, tx := range bundle.Transactions {
+		signedTx, err := signer.SignTx(bundle.txSigner(), tx.Transaction())
+		if err != nil {
+			return Bundle{}, err
+		}
+		data, err := rlp.EncodeToBytes(signedTx)
+		if err != nil {
+			return Bundle{}, err
+		}
+		hash := signedTx.Hash()
+		tx.Signed = data
+		tx.Hash = &hash
+		signed.Transactions[i] = tx
+	}
+	return signed, nil
+}
+
+// SignWithKeystore signs every transaction of a bundle with the key of a
+// keystore file.
+func SignWithKeystore(bundle Bundle, path, passphrase string) (Bundle, error) {
+	signer, err := trader.NewKeystoreSigner(path, passphrase)
+	if err != nil {
+		return Bundle{}, err
+	}
+	return Sign(bundle, signer)
+}
+
+// Broadcast sends the transactions of a signed bundle in order, waiting for
+// each to be confirmed by the number of blocks required by the client before
+// sending the next. Transactions that the node already knows about are not
+// sent again, so a broadcast that was interrupted can be repeated.
+func Broadcast(ctx context.Context, c client.Client, bundle Bundle) ([]client.Confirmation, error) {
+	if !bundle.Signed() {
+		return nil, ErrNotSigned
+	}
+	if chainID := c.ChainID(); chainID != nil && chainID.Uint64() != bundle.ChainID {
+		return nil, ErrWrongChain
+	}
+
+	txs := make([]*types.Transaction, len(bundle.Transactions))
+	for i, tx := range bundle.Transactions {
+		signed := new(types.Transaction)
+		if err := rlp.DecodeBytes(tx.Signed, signed); err != nil {
+			return nil, err
+		}
+		from, err := types.Sender(bundle.txSigner(), signed)
+		if err != nil {
+			return nil, err
+		}
+		if from != bundle.From {
+			return nil, ErrWrongSigner
+		}
+		txs[i] = signed
+	}
+
+	confirmations := make([]client.Confirmation, 0, len(txs))
+	for _, tx := range txs {
+		if _, _, err := c.Client().TransactionByHash(ctx, tx.Hash()); err == ethereum.NotFound {
+			if err := c.Client().SendTransaction(ctx, tx); err != nil {
+				return confirmations, err
+			}
+		} else if err != nil {
+			return confirmations, err
+		}
+		conf, err := c.WaitConfirmed(ctx, tx, c.Confirmations())
+		if err != nil {
+			return confirmations, err
+		}
+		confirmations = append(confirmations, conf)
+	}
+	return confirmations, nil
+}
+
+// txSigner returns the signing scheme for the transactions of a bundle.
+func (bundle Bundle) txSigner() types.Signer {
+	return trader.TxSigner(new(big.Int).SetUint64(bundle.ChainID))
+}
@@ -0,0 +1,343 @@
+package orderbook
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/store"
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// DefaultSyncInterval is the time between syncs of an Index once it has
+// started syncing.
+const DefaultSyncInterval = 15 * time.Second
+
+// indexPageSize is the number of orders fetched from the Orderbook contract
+// in one call, and the number of orders kept under one key of the store.
+const indexPageSize = 500
+
+// ErrIndexClosed is returned when an Index is synced or queried after it has
+// been closed.
+var ErrIndexClosed = errors.New("index closed")
+
+type indexEntry struct {
+	ID     order.ID     `json:"id"`
+	Trader string       `json:"trader"`
+	Status order.Status `json:"status"`
+}
+
+// Index is a local copy of the orderbook, indexed by trader and status. It
+// is kept in a store, and synced with the Orderbook contract in the
+// background by fetching the orders opened since the last sync and
+// refreshing the pages of the orderbook that have open orders.
+type Index struct {
+	orderbookContract *bindings.OrderbookCaller
+	store             store.StoreAdapter
+	interval          time.Duration
+
+	syncMu    *sync.Mutex
+	once      *sync.Once
+	closeOnce *sync.Once
+	done      chan struct{}
+
+	mu       *sync.RWMutex
+	synced   bool
+	entries  []indexEntry
+	byTrader map[string][]int
+	byStatus map[order.Status][]int
+}
+
+// NewIndex returns an Index of the orderbook of a client that is kept in a
+// store. The orders that were synced before are loaded from the store. The
+// Index starts syncing the first time it is queried.
+func NewIndex(c client.Client, storeAdapter store.StoreAdapter, interval time.Duration) (*Index, error) {
+	orderbookContract, err := bindings.NewOrderbookCaller(c.OrderbookAddress(), c.Client())
+	if err != nil {
+		return nil, err
+	}
+	if interval == 0 {
+		interval = DefaultSyncInterval
+	}
+	index := &Index{
+		orderbookContract: orderbookContract,
+		store:             storeAdapter,
+		interval:          interval,
+		syncMu:            new(sync.Mutex),
+		once:              new(sync.Once),
+		closeOnce:         new(sync.Once),
+		done:              make(chan struct{}),
+		mu:                new(sync.RWMutex),
+		entries:           []indexEntry{},
+		byTrader:          map[string][]int{},
+		byStatus:          map[order.Status][]int{},
+	}
+	if err := index.load(); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// OrdersByTrader returns the orders of a trader in the order that they were
+// opened.
+func (index *Index) OrdersByTrader(ctx context.Context, trader string) ([]order.ID, error) {
+	if index.closed() {
+		return nil, ErrIndexClosed
+	}
+	if err := index.start(ctx); err != nil {
+		return nil, err
+	}
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	return index.ids(index.byTrader[strings.ToLower(trader)]), nil
+}
+
+// OrdersByStatus returns the orders with a status in the order that they were
+// opened.
+func (index *Index) OrdersByStatus(ctx context.Context, status order.Status) ([]order.ID, error) {
+	if index.closed() {
+		return nil, ErrIndexClosed
+	}
+	if err := index.start(ctx); err != nil {
+		return nil, err
+	}
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	return index.ids(index.byStatus[status]), nil
+}
+
+// Close stops syncing the Index, and waits for a sync in progress to stop so
+// that the store can be closed after it. An Index that is closed before it is
+// queried never starts syncing in the background, and syncs and queries after
+// Close return ErrIndexClosed.
+func (index *Index) Close() error {
+	index.once.Do(func() {})
+	index.closeOnce.Do(func() {
+		close(index.done)
+	})
+	index.syncMu.Lock()
+	index.syncMu.Unlock()
+	return nil
+}
+
+func (index *Index) closed() bool {
+	select {
+	case <-index.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// start syncs the index if it has never been synced, and starts syncing it in
+// the background.
+func (index *Index) start(ctx context.Context) error {
+	index.mu.RLock()
+	synced := index.synced
+	index.mu.RUnlock()
+	if !synced {
+		if err := index.Sync(ctx); err != nil {
+			return err
+		}
+	}
+	index.once.Do(func() {
+		go index.run()
+	})
+	return nil
+}
+
+func (index *Index) run() {
+	// Syncs are stopped when the Index is closed. Pages are saved as they are
+	// fetched, so a sync that is stopped is continued by the next one.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-index.done
+		cancel()
+	}()
+
+	ticker := time.NewTicker(index.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-index.done:
+			return
+		case <-ticker.C:
+		}
+		// Errors are retried at the next sync
+		index.Sync(ctx)
+	}
+}
+
+// Sync fetches the orders opened since the last sync, and refreshes the
+// status of open orders by fetching the pages of the orderbook that have open
+// orders. Each page is saved once it has been fetched. It returns
+// ErrIndexClosed once the Index has been closed.
+func (index *Index) Sync(ctx context.Context) error {
+	index.syncMu.Lock()
+	defer index.syncMu.Unlock()
+
+	// Close waits for the lock after marking the Index as closed, so a sync
+	// that starts after it never writes to a store that may have been closed
+	if index.closed() {
+		return ErrIndexClosed
+	}
+
+	count, err := index.orderbookContract.OrdersCount(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return err
+	}
+
+	index.mu.RLock()
+	seen := len(index.entries)
+	pages := []int{}
+	for _, i := range index.byStatus[order.Open] {
+		if page := i / indexPageSize; len(pages) == 0 || pages[len(pages)-1] != page {
+			pages = append(pages, page)
+		}
+	}
+	index.mu.RUnlock()
+
+	// Fetch the new orders before refreshing statuses, so that an order that
+	// changes status during the sync is refreshed at the next sync. Calls end
+	// at page boundaries so that every call fills at most one page.
+	for offset := seen; offset < int(count.Int64()); {
+		limit := indexPageSize - offset%indexPageSize
+		ids, traders, states, err := index.orderbookContract.GetOrders(&bind.CallOpts{Context: ctx}, big.NewInt(int64(offset)), big.NewInt(int64(limit)))
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		index.mu.Lock()
+		for i := range ids {
+			index.add(indexEntry{
+				ID:     order.ID(ids[i]),
+				Trader: strings.ToLower(traders[i].Hex()),
+				Status: order.Status(states[i]),
+			})
+		}
+		index.mu.Unlock()
+		if err := index.save(offset / indexPageSize); err != nil {
+			return err
+		}
+		offset += len(ids)
+	}
+
+	for _, page := range pages {
+		offset := page * indexPageSize
+		_, _, states, err := index.orderbookContract.GetOrders(&bind.CallOpts{Context: ctx}, big.NewInt(int64(offset)), big.NewInt(indexPageSize))
+		if err != nil {
+			return err
+		}
+		changed := false
+		index.mu.Lock()
+		for i := range states {
+			if status := order.Status(states[i]); offset+i < len(index.entries) && index.entries[offset+i].Status != status {
+				index.setStatus(offset+i, status)
+				changed = true
+			}
+		}
+		index.mu.Unlock()
+		if changed {
+			if err := index.save(page); err != nil {
+				return err
+			}
+		}
+	}
+
+	index.mu.Lock()
+	index.synced = true
+	index.mu.Unlock()
+	return nil
+}
+
+func (index *Index) add(entry indexEntry) {
+	i := len(index.entries)
+	index.entries = append(index.entries, entry)
+	index.byTrader[entry.Trader] = append(index.byTrader[entry.Trader], i)
+	index.byStatus[entry.Status] = append(index.byStatus[entry.Status], i)
+}
+
+func (index *Index) setStatus(i int, status order.Status) {
+	old := index.entries[i].Status
+	positions := index.byStatus[old]
+	for j := range positions {
+		if positions[j] == i {
+			index.byStatus[old] = append(positions[:j], positions[j+1:]...)
+			break
+		}
+	}
+	index.entries[i].Status = status
+
+	// Keep the positions sorted, so that orders are listed in the order that
+	// they were opened
+	positions = index.byStatus[status]
+	j := len(positions)
+	for j > 0 && positions[j-1] > i {
+		j--
+	}
+	positions = append(positions, 0)
+	copy(positions[j+1:], positions[j:])
+	positions[j] = i
+	index.byStatus[status] = positions
+}
+
+func (index *Index) ids(positions []int) []order.ID {
+	ids := make([]order.ID, len(positions))
+	for i, position := range positions {
+		ids[i] = index.entries[position].ID
+	}
+	return ids
+}
+
+func (index *Index) load() error {
+	for page := 0; ; page++ {
+		data, err := index.store.Read(pageKey(page))
+		if err == store.ErrOrdersNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		entries := []indexEntry{}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			index.add(entry)
+		}
+		if len(entries) < indexPageSize {
+			return nil
+		}
+	}
+}
+
+func (index *Index) save(page int) error {
+	index.mu.RLock()
+	end := (page + 1) * indexPageSize
+	if end > len(index.entries) {
+		end = len(index.entries)
+	}
+	data, err := json.Marshal(index.entries[page*indexPageSize : end])
+	index.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return index.store.Write(pageKey(page), data)
+}
+
+func pageKey(page int) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, uint32(page))
+	return append([]byte("INDEX"), key...)
+}
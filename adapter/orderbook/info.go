@@ -0,0 +1,104 @@
+package orderbook
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/republicprotocol/renex-sdk-go/core/orderbook"
+	"github.com/republicprotocol/republic-go/order"
+)
+
+func (adapter *adapter) OrderInfo(ctx context.Context, id order.ID) (orderbook.OrderInfo, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	info := orderbook.OrderInfo{ID: id}
+
+	state, err := adapter.orderbookContract.OrderState(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Status = order.Status(state)
+	trader, err := adapter.orderbookContract.OrderTrader(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Trader = trader.String()
+	confirmer, err := adapter.orderbookContract.OrderConfirmer(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Confirmer = confirmer.String()
+	match, err := adapter.orderbookContract.OrderMatch(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Match = order.ID(match)
+	blockNumber, err := adapter.orderbookContract.OrderBlockNumber(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.BlockNumber = blockNumber.Uint64()
+	priority, err := adapter.orderbookContract.OrderPriority(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Priority = priority.Uint64()
+	depth, err := adapter.orderbookContract.OrderDepth(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Depth = depth.Uint64()
+
+	settlementStatus, err := adapter.renexSettlementContract.OrderStatus(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.SettlementStatus = orderbook.SettlementStatus(settlementStatus)
+	if info.SettlementStatus == orderbook.SettlementStatusNone {
+		return info, nil
+	}
+
+	// The order has been submitted to the RenExSettlement contract
+	submitter, err := adapter.renexSettlementContract.OrderSubmitter(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Submitter = submitter.String()
+	details, err := adapter.renexSettlementContract.OrderDetails(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.Details = &orderbook.OrderDetails{
+		SettlementID:  details.SettlementID,
+		Tokens:        order.Tokens(details.Tokens),
+		Price:         details.Price,
+		Volume:        details.Volume,
+		MinimumVolume: details.MinimumVolume,
+	}
+	if info.Match != (order.ID{}) {
+		timestamp, err := adapter.renexSettlementContract.MatchTimestamp(opts, id, info.Match)
+		if err != nil {
+			return orderbook.OrderInfo{}, err
+		}
+		info.MatchTimestamp = timestamp.Uint64()
+	}
+	if info.SettlementStatus != orderbook.SettlementStatusSettled && info.SettlementStatus != orderbook.SettlementStatusSlashed {
+		return info, nil
+	}
+
+	matchDetails, err := adapter.renexSettlementContract.GetMatchDetails(opts, id)
+	if err != nil {
+		return orderbook.OrderInfo{}, err
+	}
+	info.MatchDetails = &orderbook.MatchDetails{
+		Settled:         matchDetails.Settled,
+		OrderIsBuy:      matchDetails.OrderIsBuy,
+		MatchedID:       order.ID(matchDetails.MatchedID),
+		PriorityVolume:  matchDetails.PriorityVolume,
+		SecondaryVolume: matchDetails.SecondaryVolume,
+		PriorityFee:     matchDetails.PriorityFee,
+		SecondaryFee:    matchDetails.SecondaryFee,
+		PriorityToken:   order.Token(matchDetails.PriorityToken),
+		SecondaryToken:  order.Token(matchDetails.SecondaryToken),
+	}
+	return info, nil
+}
@@ -1,21 +1,19 @@
 package orderbook
 
 import (
-	"bytes"
+	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/republicprotocol/republic-go/shamir"
-	"io/ioutil"
 	"math/big"
-	"net/http"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/republicprotocol/renex-ingress-go/httpadapter"
 	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
 	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/ingress"
 	"github.com/republicprotocol/renex-sdk-go/adapter/store"
 	"github.com/republicprotocol/renex-sdk-go/adapter/trader"
 	"github.com/republicprotocol/renex-sdk-go/core/funds"
@@ -25,40 +23,72 @@ import (
 )
 
 type adapter struct {
-	httpAddress             string
-	republicBinder          contract.Binder
+	ingressClient           *ingress.Client
+	republicBinder          *contract.Binder
+	orderbookContract       *bindings.Orderbook
 	renexSettlementContract *bindings.RenExSettlement
 	trader                  trader.Trader
 	client                  client.Client
 	funds                   funds.Funds
 	store                   store.Store
+	settlementID            uint64
+	index                   *Index
 }
 
-func NewAdapter(httpAddress string, client client.Client, trader trader.Trader, funds funds.Funds, store store.Store, network string) (orderbook.Adapter, error) {
-	conn, err := contract.Connect(contract.Config{Network: contract.Network(network)})
+func NewAdapter(ingressClient *ingress.Client, client client.Client, trader trader.Trader, funds funds.Funds, store store.Store, network client.Network, index *Index) (orderbook.Adapter, error) {
+	// Orders are opened, canceled and read through the Orderbook bindings, so
+	// that they use the context, nonces and gas strategy of the trader. The
+	// republic-go binder is only used to find the pods that order fragments
+	// are sent to, and the local network has no darknodes to connect to.
+	var republicBinder *contract.Binder
+	if !network.IsLocal() {
+		conn, err := contract.Connect(contract.Config{
+			Network:                 contract.Network(network.Name),
+			URI:                     network.URL,
+			DarknodeRegistryAddress: client.DarknodeRegistryAddress().Hex(),
+			OrderbookAddress:        client.OrderbookAddress().Hex(),
+			RenExBalancesAddress:    client.RenExBalancesAddress().Hex(),
+			RenExSettlementAddress:  client.RenExSettlementAddress().Hex(),
+			RenExAtomicInfoAddress:  network.RenExAtomicInfoAddress,
+		})
+		if err != nil {
+			return nil, err
+		}
+		binder, err := contract.NewBinder(trader.TransactOpts(), conn)
+		if err != nil {
+			return nil, err
+		}
+		republicBinder = &binder
+	}
+
+	orderbookContract, err := bindings.NewOrderbook(client.OrderbookAddress(), bind.ContractBackend(client.Client()))
 	if err != nil {
 		return nil, err
 	}
-
-	republicBinder, err := contract.NewBinder(trader.TransactOpts(), conn)
-
 	renexSettlement, err := bindings.NewRenExSettlement(client.RenExSettlementAddress(), bind.ContractBackend(client.Client()))
 	if err != nil {
 		return nil, err
 	}
+	settlementID, err := renexSettlement.RENEXSETTLEMENTID(&bind.CallOpts{})
+	if err != nil {
+		return nil, err
+	}
 	return &adapter{
 		republicBinder:          republicBinder,
+		orderbookContract:       orderbookContract,
 		renexSettlementContract: renexSettlement,
-		httpAddress:             httpAddress,
+		settlementID:            uint64(settlementID),
+		ingressClient:           ingressClient,
 		trader:                  trader,
 		client:                  client,
 		funds:                   funds,
 		store:                   store,
+		index:                   index,
 	}, nil
 }
 
-func (adapter *adapter) RequestOpenOrder(order order.Order) error {
-	if err := adapter.BalanceCheck(order); err != nil {
+func (adapter *adapter) RequestOpenOrder(ctx context.Context, order order.Order) error {
+	if err := adapter.BalanceCheck(ctx, order); err != nil {
 		return err
 	}
 
@@ -73,89 +103,115 @@ func (adapter *adapter) RequestOpenOrder(order order.Order) error {
 		OrderFragmentMappings: []httpadapter.OrderFragmentMapping{mapping},
 	}
 
-	data, err := json.MarshalIndent(req, "", "  ")
+	sigBytes, err := adapter.ingressClient.OpenOrder(ctx, req)
 	if err != nil {
 		return err
 	}
-	buf := bytes.NewBuffer(data)
 
-	resp, err := http.DefaultClient.Post(fmt.Sprintf("%s/orders", adapter.httpAddress), "application/json", buf)
+	sig, err := toBytes65(sigBytes)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if !(resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK) {
-		return fmt.Errorf("Unexpected status code %d", resp.StatusCode)
-	}
 
-	respBytes, err := ioutil.ReadAll(resp.Body)
+	tx, err := adapter.trader.SendTx(ctx, func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		tx, err := adapter.orderbookContract.OpenOrder(opts, adapter.settlementID, sig[:], order.ID)
+		return adapter.client, tx, err
+	})
 	if err != nil {
 		return err
 	}
-
-	type Response struct {
-		Signature string `json:"signature"`
-	}
-
-	response := Response{}
-	if err := json.Unmarshal(respBytes, &response); err != nil {
+	if _, err := adapter.client.WaitTillMined(ctx, tx); err != nil {
 		return err
 	}
 
-	sigBytes, err := base64.StdEncoding.DecodeString(response.Signature)
-	if err != nil {
-		return err
-	}
+	return adapter.store.AppendOrder(order)
+}
 
-	sig, err := toBytes65(sigBytes)
+func (adapter *adapter) RequestCancelOrder(ctx context.Context, orderID order.ID) error {
+	tx, err := adapter.trader.SendTx(ctx, func(opts *bind.TransactOpts) (client.Client, *types.Transaction, error) {
+		tx, err := adapter.orderbookContract.CancelOrder(opts, orderID)
+		return adapter.client, tx, err
+	})
 	if err != nil {
 		return err
 	}
-
-	if err := adapter.republicBinder.OpenOrder(1, sig, order.ID); err != nil {
-		return err
-	}
-
-	return adapter.store.AppendOrder(order)
-}
-
-func (adapter *adapter) RequestCancelOrder(orderID order.ID) error {
-	if err := adapter.republicBinder.CancelOrder(orderID); err != nil {
+	if _, err := adapter.client.WaitTillMined(ctx, tx); err != nil {
 		return err
 	}
 	return adapter.store.DeleteOrder(orderID)
 }
 
-func (adapter *adapter) ListOrders() ([]order.ID, []order.Status, []string, error) {
-	numOrders, err := adapter.republicBinder.OrderCounts()
+func (adapter *adapter) ListOrders(ctx context.Context) ([]order.ID, []order.Status, []string, error) {
+	orderCount, err := adapter.OrdersCount(ctx)
 	if err != nil {
 		return nil, nil, nil, err
 	}
-	orderCount := int(numOrders)
 	orderIDs := make([]order.ID, 0, orderCount)
 	addresses := make([]string, 0, orderCount)
 	statuses := make([]order.Status, 0, orderCount)
 
-	start := 0
 	limit := 500
-	if orderCount < limit {
-		return adapter.republicBinder.Orders(0, orderCount)
-	}
-
-	for {
-		if orderCount-start < 0 {
-			return orderIDs, statuses, addresses, nil
+	for start := 0; start < orderCount; start += limit {
+		if orderCount-start < limit {
+			limit = orderCount - start
 		}
-		orderIDValues, statusValues, addressValues, err := adapter.republicBinder.Orders(start, limit)
+		orderIDValues, statusValues, addressValues, err := adapter.orders(ctx, start, limit)
 		if err != nil {
 			return nil, nil, nil, err
 		}
 		orderIDs = append(orderIDs, orderIDValues...)
 		addresses = append(addresses, addressValues...)
 		statuses = append(statuses, statusValues...)
-		start += limit
 	}
+	return orderIDs, statuses, addresses, nil
+}
+
+// ListOrdersByTrader returns the orders of a trader from the index. The
+// orderbook is scanned when the adapter has no index.
+func (adapter *adapter) ListOrdersByTrader(ctx context.Context, address string) ([]order.ID, error) {
+	if adapter.index != nil {
+		return adapter.index.OrdersByTrader(ctx, address)
+	}
+	ids, _, addresses, err := adapter.ListOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orderIDs := []order.ID{}
+	for i, id := range ids {
+		if addresses[i] == address {
+			orderIDs = append(orderIDs, id)
+		}
+	}
+	return orderIDs, nil
+}
+
+// ListOrdersByStatus returns the orders with a status from the index. The
+// orderbook is scanned when the adapter has no index.
+func (adapter *adapter) ListOrdersByStatus(ctx context.Context, status order.Status) ([]order.ID, error) {
+	if adapter.index != nil {
+		return adapter.index.OrdersByStatus(ctx, status)
+	}
+	ids, statuses, _, err := adapter.ListOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orderIDs := []order.ID{}
+	for i, id := range ids {
+		if statuses[i] == status {
+			orderIDs = append(orderIDs, id)
+		}
+	}
+	return orderIDs, nil
+}
+
+// OpenedOrder returns an order that was opened by the trader from the store,
+// and false if the store does not have the order.
+func (adapter *adapter) OpenedOrder(ctx context.Context, id order.ID) (order.Order, bool, error) {
+	ord, err := adapter.store.Order(id)
+	if err != nil {
+		return order.Order{}, false, err
+	}
+	return ord, ord.ID == id, nil
 }
 
 func (adapter *adapter) Sign(data []byte) ([]byte, error) {
@@ -166,12 +222,64 @@ func (adapter *adapter) Address() []byte {
 	return adapter.trader.Address().Bytes()
 }
 
-func (adapter *adapter) Status(id order.ID) (order.Status, error) {
-	return adapter.republicBinder.Status(id)
+func (adapter *adapter) Status(ctx context.Context, id order.ID) (order.Status, error) {
+	state, err := adapter.orderbookContract.OrderState(&bind.CallOpts{Context: ctx}, id)
+	if err != nil {
+		return 0, err
+	}
+	return order.Status(state), nil
+}
+
+func (adapter *adapter) Match(ctx context.Context, id order.ID) (order.ID, error) {
+	match, err := adapter.orderbookContract.OrderMatch(&bind.CallOpts{Context: ctx}, id)
+	if err != nil {
+		return order.ID{}, err
+	}
+	return order.ID(match), nil
+}
+
+func (adapter *adapter) OrdersCount(ctx context.Context) (int, error) {
+	count, err := adapter.orderbookContract.OrdersCount(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Int64()), nil
+}
+
+func (adapter *adapter) Orders(ctx context.Context, offset, limit int) ([]order.ID, []order.Status, []string, error) {
+	return adapter.orders(ctx, offset, limit)
+}
+
+func (adapter *adapter) BlockNumber(ctx context.Context) (uint64, error) {
+	header, err := adapter.client.Client().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Uint64(), nil
+}
+
+func (adapter *adapter) TraderAddress() string {
+	return adapter.trader.Address().String()
+}
+
+func (adapter *adapter) orders(ctx context.Context, offset, limit int) ([]order.ID, []order.Status, []string, error) {
+	ids, traders, states, err := adapter.orderbookContract.GetOrders(&bind.CallOpts{Context: ctx}, big.NewInt(int64(offset)), big.NewInt(int64(limit)))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	orderIDs := make([]order.ID, len(ids))
+	statuses := make([]order.Status, len(ids))
+	addresses := make([]string, len(ids))
+	for i := range ids {
+		orderIDs[i] = order.ID(ids[i])
+		statuses[i] = order.Status(states[i])
+		addresses[i] = traders[i].String()
+	}
+	return orderIDs, statuses, addresses, nil
 }
 
-func (adapter *adapter) Settled(id order.ID) (bool, error) {
-	det, err := adapter.renexSettlementContract.GetMatchDetails(&bind.CallOpts{}, id)
+func (adapter *adapter) Settled(ctx context.Context, id order.ID) (bool, error) {
+	det, err := adapter.renexSettlementContract.GetMatchDetails(&bind.CallOpts{Context: ctx}, id)
 	if err != nil {
 		return false, err
 	}
@@ -179,6 +287,14 @@ func (adapter *adapter) Settled(id order.ID) (bool, error) {
 }
 
 func (adapter *adapter) buildOrderMapping(ord order.Order) (httpadapter.OrderFragmentMapping, error) {
+	if adapter.republicBinder == nil {
+		// Without pods the ingress only needs the order ID to approve the
+		// order
+		return httpadapter.OrderFragmentMapping{
+			"": []httpadapter.OrderFragment{{OrderID: base64.StdEncoding.EncodeToString(ord.ID[:])}},
+		}, nil
+	}
+
 	pods, err := adapter.republicBinder.Pods()
 	if err != nil {
 		return nil, err
@@ -247,15 +363,15 @@ func (adapter *adapter) buildOrderMapping(ord order.Order) (httpadapter.OrderFra
 	return orderFragmentMapping, nil
 }
 
-func (adapter *adapter) BalanceCheck(order order.Order) error {
+func (adapter *adapter) BalanceCheck(ctx context.Context, order order.Order) error {
 	token := getTokenCode(order)
-	balance, err := adapter.funds.UsableRenExBalance(token)
+	balance, err := adapter.funds.UsableRenExBalanceContext(ctx, token)
 	if err != nil {
 		return err
 	}
 	volume := big.NewInt(int64(order.Volume))
 	if balance.Cmp(volume) < 0 {
-		return fmt.Errorf("[%v] Order volume exceeded usable balance have:%v want:%v", token, balance, volume)
+		return funds.InsufficientBalanceError{Token: token, Have: balance, Want: volume}
 	}
 	return nil
 }
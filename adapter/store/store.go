@@ -19,7 +19,7 @@ type store struct {
 }
 
 type orders struct {
-	ids []order.ID `json:"id"`
+	IDs []order.ID `json:"ids"`
 }
 
 type StoreAdapter interface {
@@ -32,6 +32,7 @@ type StoreAdapter interface {
 type Store interface {
 	RequestLockedBalance(order.Token) (*big.Int, error)
 	OpenOrdersExist(order.Token) (bool, error)
+	Order(order.ID) (order.Order, error)
 	AppendOrder(order.Order) error
 	DeleteOrder(order.ID) error
 }
@@ -80,7 +81,7 @@ func (store *store) openOrders(tokenCode order.Token) ([]order.Order, error) {
 	}
 
 	orders := []order.Order{}
-	for _, id := range orderList.ids {
+	for _, id := range orderList.IDs {
 		ord, err := store.Order(id)
 		if err != nil {
 			return nil, err
@@ -129,7 +130,12 @@ func (store *store) AppendOrder(ord order.Order) error {
 		}
 	}
 
-	orderList.ids = append(orderList.ids, ord.ID)
+	for _, id := range orderList.IDs {
+		if id == ord.ID {
+			return nil
+		}
+	}
+	orderList.IDs = append(orderList.IDs, ord.ID)
 	orderListData, err := json.Marshal(orderList)
 	if err != nil {
 		return err
@@ -152,7 +158,7 @@ func (store *store) DeleteOrder(id order.ID) error {
 	if err := json.Unmarshal(data, &orderList); err != nil {
 		return err
 	}
-	orderList.ids = deleteFromList(orderList.ids, id)
+	orderList.IDs = deleteFromList(orderList.IDs, id)
 	orderListData, err := json.Marshal(orderList)
 	if err != nil {
 		return err
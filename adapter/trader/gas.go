@@ -0,0 +1,153 @@
+package trader
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+)
+
+// DefaultGasLimitMargin is the percentage added to the estimated gas limit of
+// a transaction, so that it does not run out of gas when the state it touches
+// changes before it is mined.
+const DefaultGasLimitMargin = 20
+
+// GasStrategy chooses the gas price of a transaction.
+type GasStrategy interface {
+	GasPrice(ctx context.Context, backend client.Backend) (*big.Int, error)
+}
+
+type fixedGasStrategy struct {
+	price *big.Int
+}
+
+// NewFixedGasStrategy returns a GasStrategy that always uses the same gas
+// price.
+func NewFixedGasStrategy(price *big.Int) GasStrategy {
+	return &fixedGasStrategy{
+		price: new(big.Int).Set(price),
+	}
+}
+
+func (strategy *fixedGasStrategy) GasPrice(ctx context.Context, backend client.Backend) (*big.Int, error) {
+	return new(big.Int).Set(strategy.price), nil
+}
+
+type suggestedGasStrategy struct{}
+
+// NewSuggestedGasStrategy returns a GasStrategy that uses the gas price
+// suggested by the Ethereum node.
+func NewSuggestedGasStrategy() GasStrategy {
+	return &suggestedGasStrategy{}
+}
+
+func (strategy *suggestedGasStrategy) GasPrice(ctx context.Context, backend client.Backend) (*big.Int, error) {
+	return backend.SuggestGasPrice(ctx)
+}
+
+type blockBackend interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+type percentileGasStrategy struct {
+	blocks     int
+	percentile int
+
+	// The gas price is cached for the head block that it was computed at
+	mu    *sync.Mutex
+	head  common.Hash
+	price *big.Int
+}
+
+// NewPercentileGasStrategy returns a GasStrategy that uses a percentile of the
+// gas prices paid by the transactions in the most recent blocks. When the
+// backend cannot return blocks, or the blocks are empty, the gas price
+// suggested by the node is used instead. The gas price is only computed again
+// when a new block is mined.
+func NewPercentileGasStrategy(blocks, percentile int) GasStrategy {
+	if percentile < 0 {
+		percentile = 0
+	}
+	if percentile > 100 {
+		percentile = 100
+	}
+	return &percentileGasStrategy{
+		blocks:     blocks,
+		percentile: percentile,
+		mu:         new(sync.Mutex),
+	}
+}
+
+func (strategy *percentileGasStrategy) GasPrice(ctx context.Context, backend client.Backend) (*big.Int, error) {
+	blocks, ok := backend.(blockBackend)
+	if !ok {
+		return backend.SuggestGasPrice(ctx)
+	}
+
+	head, err := blocks.BlockByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy.mu.Lock()
+	defer strategy.mu.Unlock()
+	if strategy.price != nil && strategy.head == head.Hash() {
+		return new(big.Int).Set(strategy.price), nil
+	}
+
+	prices := []*big.Int{}
+	for block, i := head, 0; i < strategy.blocks; i++ {
+		for _, tx := range block.Transactions() {
+			prices = append(prices, tx.GasPrice())
+		}
+		if block.NumberU64() == 0 || i == strategy.blocks-1 {
+			break
+		}
+		if block, err = blocks.BlockByNumber(ctx, new(big.Int).Sub(block.Number(), big.NewInt(1))); err != nil {
+			return nil, err
+		}
+	}
+	if len(prices) == 0 {
+		return backend.SuggestGasPrice(ctx)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].Cmp(prices[j]) < 0
+	})
+	strategy.head = head.Hash()
+	strategy.price = new(big.Int).Set(prices[(len(prices)-1)*strategy.percentile/100])
+	return new(big.Int).Set(strategy.price), nil
+}
+
+type cappedGasStrategy struct {
+	strategy GasStrategy
+	max      *big.Int
+}
+
+// NewCappedGasStrategy returns a GasStrategy that uses the gas price of
+// another strategy, but never more than a maximum gas price.
+func NewCappedGasStrategy(strategy GasStrategy, max *big.Int) GasStrategy {
+	return &cappedGasStrategy{
+		strategy: strategy,
+		max:      new(big.Int).Set(max),
+	}
+}
+
+func (strategy *cappedGasStrategy) GasPrice(ctx context.Context, backend client.Backend) (*big.Int, error) {
+	price, err := strategy.strategy.GasPrice(ctx, backend)
+	if err != nil {
+		return nil, err
+	}
+	return minGasPrice(price, strategy.max), nil
+}
+
+func minGasPrice(price, max *big.Int) *big.Int {
+	if max != nil && price.Cmp(max) > 0 {
+		return new(big.Int).Set(max)
+	}
+	return price
+}
@@ -0,0 +1,192 @@
+package trader
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/republicprotocol/republic-go/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultBasePath is the BIP-44 path of Ethereum accounts. The account with
+// index i is derived at DefaultBasePath/i.
+const DefaultBasePath = "m/44'/60'/0'/0"
+
+// KeystoreFormat is the format in which a derived key is written to disk.
+type KeystoreFormat int
+
+// Values for KeystoreFormat. Both formats can be read by NewTrader.
+const (
+	KeystoreFormatGeth KeystoreFormat = iota
+	KeystoreFormatRepublic
+)
+
+// ErrInvalidChildKey is returned for the rare indices at which BIP-32 does
+// not define a key. The next index should be used instead.
+var ErrInvalidChildKey = errors.New("invalid child key")
+
+// Account is a trading account derived from a mnemonic.
+type Account struct {
+	Path    string
+	Address common.Address
+	Key     *ecdsa.PrivateKey
+}
+
+// NewMnemonic returns a random 24 word BIP-39 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NewTraderFromMnemonic returns a Trader that signs with the key derived from
+// a BIP-39 mnemonic and optional passphrase at a BIP-32 derivation path, such
+// as "m/44'/60'/0'/0/0".
+func NewTraderFromMnemonic(mnemonic, passphrase, path string, options ...Option) (Trader, error) {
+	key, err := DeriveKey(mnemonic, passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewTraderFromSigner(NewKeySigner(key), options...), nil
+}
+
+// DeriveAccounts derives count accounts from a mnemonic, starting at index
+// start below the base path. An empty base path uses DefaultBasePath.
+func DeriveAccounts(mnemonic, passphrase, basePath string, start, count int) ([]Account, error) {
+	if basePath == "" {
+		basePath = DefaultBasePath
+	}
+	accs := make([]Account, 0, count)
+	for i := start; i < start+count; i++ {
+		path := fmt.Sprintf("%s/%d", strings.TrimRight(basePath, "/"), i)
+		key, err := DeriveKey(mnemonic, passphrase, path)
+		if err != nil {
+			return nil, err
+		}
+		accs = append(accs, Account{
+			Path:    path,
+			Address: ethCrypto.PubkeyToAddress(key.PublicKey),
+			Key:     key,
+		})
+	}
+	return accs, nil
+}
+
+// DeriveKey derives the private key at a BIP-32 derivation path from a BIP-39
+// mnemonic and optional passphrase.
+func DeriveKey(mnemonic, passphrase, path string) (*ecdsa.PrivateKey, error) {
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return deriveKey(seed, derivationPath)
+}
+
+// deriveKey derives the private key at a BIP-32 derivation path from a seed.
+func deriveKey(seed []byte, derivationPath accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(ethCrypto.S256().Params().N) >= 0 {
+		return nil, ErrInvalidChildKey
+	}
+
+	var err error
+	for _, index := range derivationPath {
+		if key, chainCode, err = deriveChild(key, chainCode, index); err != nil {
+			return nil, err
+		}
+	}
+	return ethCrypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+}
+
+const hardenedIndex = 0x80000000
+
+// deriveChild derives the child private key at an index, as defined by
+// BIP-32. Indices from 2^31 are hardened.
+func deriveChild(key *big.Int, chainCode []byte, index uint32) (*big.Int, []byte, error) {
+	data := make([]byte, 0, 37)
+	if index >= hardenedIndex {
+		data = append(data, 0)
+		data = append(data, common.LeftPadBytes(key.Bytes(), 32)...)
+	} else {
+		curve := ethCrypto.S256()
+		x, y := curve.ScalarBaseMult(common.LeftPadBytes(key.Bytes(), 32))
+		data = append(data, byte(2+y.Bit(0)))
+		data = append(data, common.LeftPadBytes(x.Bytes(), 32)...)
+	}
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := ethCrypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, nil, ErrInvalidChildKey
+	}
+	child := new(big.Int).Add(tweak, key)
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, ErrInvalidChildKey
+	}
+	return child, sum[32:], nil
+}
+
+// WriteKeystore encrypts a key with a passphrase and writes it to a new file
+// in a directory. It returns the path of the file.
+func WriteKeystore(key *ecdsa.PrivateKey, dir, passphrase string, format KeystoreFormat) (string, error) {
+	switch format {
+	case KeystoreFormatGeth:
+		ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+		account, err := ks.ImportECDSA(key, passphrase)
+		if err != nil {
+			return "", err
+		}
+		return account.URL.Path, nil
+
+	case KeystoreFormatRepublic:
+		ks, err := crypto.RandomKeystore()
+		if err != nil {
+			return "", err
+		}
+		ks.EcdsaKey = crypto.NewEcdsaKey(key)
+		data, err := ks.EncryptToJSON(passphrase, keystore.StandardScryptN, keystore.StandardScryptP)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", err
+		}
+		path := filepath.Join(dir, strings.ToLower(ethCrypto.PubkeyToAddress(key.PublicKey).Hex())+".json")
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return "", err
+		}
+		return path, nil
+
+	default:
+		return "", fmt.Errorf("unknown keystore format %d", format)
+	}
+}
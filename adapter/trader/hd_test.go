@@ -0,0 +1,72 @@
+package trader
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// The private keys of the extended keys in test vectors 1 and 2 of BIP-32.
+var bip32Vectors = []struct {
+	seed string
+	path string
+	key  string
+}{
+	{"000102030405060708090a0b0c0d0e0f", "m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+	{"000102030405060708090a0b0c0d0e0f", "m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+	{"000102030405060708090a0b0c0d0e0f", "m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+	{"000102030405060708090a0b0c0d0e0f", "m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+	{"000102030405060708090a0b0c0d0e0f", "m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+	{"000102030405060708090a0b0c0d0e0f", "m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	{"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542", "m", "4b03d6fc340455b363f51020ad3ecca4f0850280cf436c70c727923f6db46c3e"},
+	{"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542", "m/0", "abe74a98f6c7eabee0428f53798f0ab8aa1bd37873999041703c742f15ac7e1e"},
+	{"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542", "m/0/2147483647'", "877c779ad9687164e9c2f4f0f4ff0340814392330693ce95a58fe18fd52e6e93"},
+	{"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542", "m/0/2147483647'/1", "704addf544a06e5ee4bea37098463c23613da32020d604506da8c0518e1da4b7"},
+	{"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542", "m/0/2147483647'/1/2147483646'", "f1c7c871a54a804afe328b4c83a1c33b8e5ff48f5087273f04efa83b247d6a2d"},
+	{"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542", "m/0/2147483647'/1/2147483646'/2", "bb7d39bdb83ecf58f2fd82b6d918341cbef428661ef01ab97c28a4842125ac23"},
+}
+
+func TestDeriveKeyBIP32Vectors(t *testing.T) {
+	for _, vector := range bip32Vectors {
+		seed, err := hex.DecodeString(vector.seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := accounts.DerivationPath{}
+		if vector.path != "m" {
+			if path, err = accounts.ParseDerivationPath(vector.path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		key, err := deriveKey(seed, path)
+		if err != nil {
+			t.Fatalf("%s: %v", vector.path, err)
+		}
+		if got := hex.EncodeToString(ethCrypto.FromECDSA(key)); got != vector.key {
+			t.Errorf("%s: got key %s, want %s", vector.path, got, vector.key)
+		}
+	}
+}
+
+func TestDeriveKeyFromMnemonic(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	accs, err := DeriveAccounts(mnemonic, "", "", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"); accs[0].Address != want {
+		t.Errorf("got address %s, want %s", accs[0].Address.Hex(), want.Hex())
+	}
+	if accs[0].Path != "m/44'/60'/0'/0/0" {
+		t.Errorf("got path %s, want m/44'/60'/0'/0/0", accs[0].Path)
+	}
+}
+
+func TestDeriveKeyInvalidMnemonic(t *testing.T) {
+	if _, err := DeriveKey("abandon abandon abandon", "", DefaultBasePath+"/0"); err == nil {
+		t.Error("expected an error for an invalid mnemonic")
+	}
+}
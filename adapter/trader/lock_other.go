@@ -0,0 +1,13 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package trader
+
+import "context"
+
+// lockFile does nothing on platforms without flock. Processes that share a key
+// on these platforms are only noticed through the pending nonce of the
+// account.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	return func() {}, nil
+}
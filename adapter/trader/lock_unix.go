@@ -0,0 +1,45 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package trader
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockPollInterval is the time between attempts to lock a file that is locked
+// by another process.
+var lockPollInterval = 50 * time.Millisecond
+
+// lockFile takes an exclusive lock on a file, creating it if needed, and
+// returns the function that unlocks it. The lock is released by the operating
+// system if the process exits while holding it.
+func lockFile(ctx context.Context, path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return func() {
+				syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+				file.Close()
+			}, nil
+		}
+		if err != syscall.EWOULDBLOCK {
+			file.Close()
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/store"
+)
+
+// NonceManager hands out the nonces of an account. Every nonce returned by
+// Next is handed out once, until it is released or the manager reconciles
+// with the chain.
+type NonceManager interface {
+	// Lock reserves the nonces of the account until the returned function is
+	// called. A nonce is taken with Next and sent while the nonces are locked,
+	// so that no other sender, in this process or another one, is handed the
+	// same nonce.
+	Lock(ctx context.Context) (func(), error)
+
+	// Next returns the next nonce to use. It must be called while the nonces
+	// are locked.
+	Next(ctx context.Context, backend client.Backend) (uint64, error)
+
+	// Release returns a nonce that was not used because its transaction was
+	// rejected, so that it is handed out again before any new nonce.
+	Release(nonce uint64) error
+
+	// Reconcile updates the manager with the pending nonce of the account on
+	// the chain, forgetting the nonces that have been used on the chain and
+	// handing out again the first nonce that has not.
+	Reconcile(ctx context.Context, backend client.Backend) error
+}
+
+type nonceState struct {
+	Next uint64   `json:"next"`
+	Gaps []uint64 `json:"gaps"`
+}
+
+type nonceManager struct {
+	address  common.Address
+	store    store.StoreAdapter
+	lockPath string
+
+	// sendMu is held by the sender that has locked the nonces, and mu guards
+	// the state
+	sendMu chan struct{}
+	mu     *sync.Mutex
+	state  nonceState
+}
+
+// NewNonceManager returns a NonceManager for an account that keeps its state
+// in a store, so that it survives restarts. A nil store keeps the state in
+// memory. The nonces of the account are locked with a file in the temporary
+// directory, so that processes on the same machine that share the key do not
+// send transactions at the same time. Processes on other machines are only
+// noticed through the pending nonce of the account, which is read every time
+// a nonce is handed out.
+func NewNonceManager(address common.Address, storeAdapter store.StoreAdapter) NonceManager {
+	return &nonceManager{
+		address:  address,
+		store:    storeAdapter,
+		lockPath: filepath.Join(os.TempDir(), fmt.Sprintf("renex-nonce-%x.lock", address.Bytes())),
+		sendMu:   make(chan struct{}, 1),
+		mu:       new(sync.Mutex),
+	}
+}
+
+func (manager *nonceManager) Lock(ctx context.Context) (func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case manager.sendMu <- struct{}{}:
+	}
+	unlock, err := lockFile(ctx, manager.lockPath)
+	if err != nil {
+		<-manager.sendMu
+		return nil, err
+	}
+	return func() {
+		unlock()
+		<-manager.sendMu
+	}, nil
+}
+
+func (manager *nonceManager) Next(ctx context.Context, backend client.Backend) (uint64, error) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	// Every nonce handed out earlier has been sent or released, since the
+	// nonces are locked, so the pending nonce on the chain is up to date
+	if err := manager.reconcile(ctx, backend); err != nil {
+		return 0, err
+	}
+
+	state := manager.state
+	var nonce uint64
+	if len(state.Gaps) > 0 {
+		nonce, state.Gaps = state.Gaps[0], state.Gaps[1:]
+	} else {
+		nonce = state.Next
+		state.Next++
+	}
+	if err := manager.write(state); err != nil {
+		return 0, err
+	}
+	return nonce, nil
+}
+
+func (manager *nonceManager) Release(nonce uint64) error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	state := manager.state
+	if nonce >= state.Next {
+		return nil
+	}
+	if nonce == state.Next-1 {
+		state.Next--
+	} else {
+		gaps := make([]uint64, 0, len(state.Gaps)+1)
+		gaps = append(gaps, state.Gaps...)
+		state.Gaps = append(gaps, nonce)
+		sort.Slice(state.Gaps, func(i, j int) bool { return state.Gaps[i] < state.Gaps[j] })
+	}
+	return manager.write(state)
+}
+
+func (manager *nonceManager) Reconcile(ctx context.Context, backend client.Backend) error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.reconcile(ctx, backend)
+}
+
+// reconcile compares the stored state with the pending nonce on the chain.
+// Nonces below the pending nonce have been used, possibly by another process
+// sharing the key, so they are dropped from the gaps. The pending nonce itself
+// has not reached the chain, so if it was handed out it is handed out again,
+// otherwise it would leave a gap that blocks every later transaction. Released
+// nonces above it are kept, and the nonces between them were sent and can
+// still be waiting in the queue of the node.
+func (manager *nonceManager) reconcile(ctx context.Context, backend client.Backend) error {
+	state, err := manager.read()
+	if err != nil {
+		return err
+	}
+	pending, err := backend.PendingNonceAt(ctx, manager.address)
+	if err != nil {
+		return err
+	}
+	return manager.write(reconcileNonces(state, pending))
+}
+
+func reconcileNonces(state nonceState, pending uint64) nonceState {
+	if pending >= state.Next {
+		return nonceState{Next: pending}
+	}
+	gaps := []uint64{pending}
+	for _, gap := range state.Gaps {
+		if gap > pending && gap < state.Next {
+			gaps = append(gaps, gap)
+		}
+	}
+	return nonceState{Next: state.Next, Gaps: gaps}
+}
+func (manager *nonceManager) key() []byte {
+	return append([]byte("NONCE"), manager.address.Bytes()...)
+}
+
+func (manager *nonceManager) read() (nonceState, error) {
+	if manager.store == nil {
+		return manager.state, nil
+	}
+	data, err := manager.store.Read(manager.key())
+	if err == store.ErrOrdersNotFound {
+		return nonceState{}, nil
+	}
+	if err != nil {
+		return nonceState{}, err
+	}
+	state := nonceState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nonceState{}, err
+	}
+	return state, nil
+}
+
+func (manager *nonceManager) write(state nonceState) error {
+	if manager.store != nil {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := manager.store.Write(manager.key(), data); err != nil {
+			return err
+		}
+	}
+	manager.state = state
+	return nil
+}
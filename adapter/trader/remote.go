@@ -0,0 +1,129 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrNoRemoteAccounts is returned when a signing daemon has no accounts.
+var ErrNoRemoteAccounts = errors.New("signer has no accounts")
+
+// ErrRemoteSignHash is returned when a remote signer is asked to sign a hash
+// instead of a message.
+var ErrRemoteSignHash = errors.New("signer cannot sign hashes")
+
+// RemoteSignerTimeout bounds every request to a signing daemon. Daemons can
+// ask an operator to approve a request, so it is generous.
+var RemoteSignerTimeout = 2 * time.Minute
+
+type remoteSigner struct {
+	rpc     *rpc.Client
+	address common.Address
+}
+
+// NewRemoteSigner returns a Signer that asks a signing daemon to sign, using
+// the JSON-RPC API of clef. The endpoint is the path of a Unix socket or a
+// localhost HTTP URL. When the address is zero, the first account of the
+// daemon is used.
+func NewRemoteSigner(endpoint string, address common.Address) (Signer, error) {
+	conn, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	signer := &remoteSigner{
+		rpc:     conn,
+		address: address,
+	}
+	if address == (common.Address{}) {
+		accounts := []common.Address{}
+		if err := signer.call(&accounts, "account_list"); err != nil {
+			return nil, err
+		}
+		if len(accounts) == 0 {
+			return nil, ErrNoRemoteAccounts
+		}
+		signer.address = accounts[0]
+	}
+	return signer, nil
+}
+
+func (signer *remoteSigner) Address() common.Address {
+	return signer.address
+}
+
+type sendTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice hexutil.Big     `json:"gasPrice"`
+	Value    hexutil.Big     `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+// SignTx asks the daemon to sign a transaction. The daemon signs with its own
+// chain configuration, so the signed transaction is checked against the
+// types.Signer to catch a daemon that is configured for another chain.
+func (signer *remoteSigner) SignTx(s types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	args := sendTxArgs{
+		From:     signer.address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+	}
+
+	result := struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}{}
+	if err := signer.call(&result, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(result.Raw, signed); err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(s, signed)
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify remote signature: %v", err)
+	}
+	if from != signer.address {
+		return nil, fmt.Errorf("cannot verify remote signature: signed by %s", from.Hex())
+	}
+	return signed, nil
+}
+
+// SignHash returns ErrRemoteSignHash, since signing daemons refuse to sign
+// arbitrary hashes.
+func (signer *remoteSigner) SignHash(hash []byte) ([]byte, error) {
+	return nil, ErrRemoteSignHash
+}
+
+// SignPersonal asks the daemon to sign a message. The daemon hashes the
+// message with the Ethereum signed message prefix.
+func (signer *remoteSigner) SignPersonal(data []byte) ([]byte, error) {
+	signature := hexutil.Bytes{}
+	if err := signer.call(&signature, "account_signData", "text/plain", signer.address, hexutil.Bytes(data)); err != nil {
+		return nil, err
+	}
+	if len(signature) != SignatureLength {
+		return nil, ErrInvalidSignature
+	}
+	return signature, nil
+}
+
+func (signer *remoteSigner) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), RemoteSignerTimeout)
+	defer cancel()
+	return signer.rpc.CallContext(ctx, result, method, args...)
+}
@@ -0,0 +1,149 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/journal"
+)
+
+// ErrTxNotPending is returned when replacing a transaction that has already
+// been mined, or that the node does not know about.
+var ErrTxNotPending = errors.New("Transaction is not pending")
+
+// ErrReplacementUnderpriced is returned when the gas price of a replacement
+// is not high enough for nodes to accept it.
+var ErrReplacementUnderpriced = errors.New("Replacement transaction underpriced")
+
+// replacementBump is the percentage by which a replacement must raise the gas
+// price of the transaction it replaces before nodes accept it.
+const replacementBump = 10
+
+// SpeedUp replaces a pending transaction with the same transaction at a higher
+// gas price. Waiting for either transaction with the client waits for
+// whichever of them is mined. When the transaction was sent as a step of an
+// operation in the journal, the replacement is recorded in the same step.
+func (t *trader) SpeedUp(ctx context.Context, c client.Client, txHash common.Hash, gasPrice *big.Int) (*types.Transaction, error) {
+	tx, err := t.pendingTx(ctx, c, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if gasPrice.Cmp(minReplacementGasPrice(tx)) < 0 {
+		return nil, ErrReplacementUnderpriced
+	}
+
+	var replacement *types.Transaction
+	if tx.To() == nil {
+		replacement = types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+	} else {
+		replacement = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+	}
+	return t.replace(ctx, c, tx, replacement, false)
+}
+
+// CancelTx replaces a pending transaction with a transfer of no ether from the
+// trader to itself at the same nonce. Waiting for the canceled transaction
+// with the client returns a client.TxCanceledError once the cancellation is
+// mined. The cancellation is recorded in the journal like a replacement sent
+// by SpeedUp.
+func (t *trader) CancelTx(ctx context.Context, c client.Client, txHash common.Hash) (*types.Transaction, error) {
+	tx, err := t.pendingTx(ctx, c, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice, err := t.gasStrategy.GasPrice(ctx, c.Client())
+	if err != nil {
+		return nil, err
+	}
+	if min := minReplacementGasPrice(tx); gasPrice.Cmp(min) < 0 {
+		gasPrice = min
+	}
+	cancellation := types.NewTransaction(tx.Nonce(), t.Address(), big.NewInt(0), 21000, gasPrice, nil)
+	return t.replace(ctx, c, tx, cancellation, true)
+}
+
+func (t *trader) pendingTx(ctx context.Context, c client.Client, txHash common.Hash) (*types.Transaction, error) {
+	tx, pending, err := c.Client().TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if !pending {
+		return nil, ErrTxNotPending
+	}
+	return tx, nil
+}
+
+func (t *trader) replace(ctx context.Context, c client.Client, tx, replacement *types.Transaction, cancel bool) (*types.Transaction, error) {
+	record, err := t.replacementJournal(tx.Hash(), cancel)
+	if err != nil {
+		return nil, err
+	}
+	chainID := c.ChainID()
+	if chainID == nil {
+		chainID = t.chainID
+	}
+	signed, err := t.signer.SignTx(TxSigner(chainID), replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	// The replacement is recorded before it is broadcast, so that it is
+	// waited for instead of the transaction it replaces when the operation is
+	// resumed
+	if err := record(signed, journal.StateSigned); err != nil {
+		return nil, err
+	}
+	if err := c.Client().SendTransaction(ctx, signed); err != nil {
+		if isRejected(err) {
+			if recordErr := record(signed, journal.StateFailed); recordErr != nil {
+				return nil, recordErr
+			}
+		}
+		return nil, err
+	}
+	c.Replace(tx.Hash(), signed.Hash(), cancel)
+	return signed, record(signed, journal.StateSent)
+}
+
+// replacementJournal returns a function that records a replacement of a
+// transaction as an entry of the pending operation and step that sent the
+// transaction. Replacements of transactions that are not in the journal are
+// not recorded.
+func (t *trader) replacementJournal(txHash common.Hash, cancel bool) (func(*types.Transaction, journal.State) error, error) {
+	noRecord := func(*types.Transaction, journal.State) error { return nil }
+	if t.journal == nil {
+		return noRecord, nil
+	}
+	ops, err := t.journal.Pending()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		for _, entry := range op.Entries {
+			if entry.Hash != txHash {
+				continue
+			}
+			id, step := op.ID, entry.Step
+			return func(tx *types.Transaction, state journal.State) error {
+				return t.journal.Record(id, journal.Entry{
+					Step:   step,
+					Hash:   tx.Hash(),
+					Nonce:  tx.Nonce(),
+					State:  state,
+					Cancel: cancel,
+				})
+			}, nil
+		}
+	}
+	return noRecord, nil
+}
+
+func minReplacementGasPrice(tx *types.Transaction) *big.Int {
+	min := new(big.Int).Mul(tx.GasPrice(), big.NewInt(100+replacementBump))
+	return min.Div(min, big.NewInt(100))
+}
@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignatureLength is the length of a [R||S||V] signature.
+const SignatureLength = 65
+
+// ErrInvalidSignature is returned when a signature is not a 65 byte
+// [R||S||V] signature.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// PersonalHash returns the hash of a message prefixed with the Ethereum
+// signed message prefix, which is the hash signed by personal_sign.
+func PersonalHash(data []byte) []byte {
+	return ethCrypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(data))), data)
+}
+
+// RecoverHash returns the address that signed a 32 byte hash. V can be either
+// 0 or 1, or 27 or 28.
+func RecoverHash(hash, signature []byte) (common.Address, error) {
+	if len(signature) != SignatureLength {
+		return common.Address{}, ErrInvalidSignature
+	}
+	sig := make([]byte, SignatureLength)
+	copy(sig, signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return common.Address{}, ErrInvalidSignature
+	}
+	pubKey, err := ethCrypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return ethCrypto.PubkeyToAddress(*pubKey), nil
+}
+
+// Recover returns the address that signed data with Trader.Sign or
+// personal_sign.
+func Recover(data, signature []byte) (common.Address, error) {
+	return RecoverHash(PersonalHash(data), signature)
+}
+
+// Verify returns true if data was signed by an address with Trader.Sign or
+// personal_sign.
+func Verify(address common.Address, data, signature []byte) bool {
+	signer, err := Recover(data, signature)
+	return err == nil && signer == address
+}
+
+// VerifyHash returns true if a 32 byte hash was signed by an address with
+// Trader.SignHash.
+func VerifyHash(address common.Address, hash, signature []byte) bool {
+	signer, err := RecoverHash(hash, signature)
+	return err == nil && signer == address
+}
+
+// OpenMessage returns the message that the RenExBrokerVerifier expects to be
+// signed when a trader opens an order.
+func OpenMessage(trader common.Address, orderID [32]byte) []byte {
+	message := append([]byte("Republic Protocol: open: "), trader.Bytes()...)
+	return append(message, orderID[:]...)
+}
+
+// WithdrawMessage returns the message that the RenExBrokerVerifier expects to
+// be signed when a trader withdraws. The nonce is the withdrawal nonce of the
+// trader in the RenExBrokerVerifier.
+func WithdrawMessage(trader common.Address, nonce *big.Int) []byte {
+	message := append([]byte("Republic Protocol: withdraw: "), trader.Bytes()...)
+	return append(message, common.LeftPadBytes(nonce.Bytes(), 32)...)
+}
@@ -0,0 +1,81 @@
+package trader
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/republicprotocol/republic-go/crypto"
+)
+
+// Signer holds the key of a trader and signs on its behalf. Signers let the
+// key live outside of the SDK process, see NewRemoteSigner.
+type Signer interface {
+	// Address returns the address of the key.
+	Address() common.Address
+
+	// SignTx signs a transaction. The types.Signer is the signing scheme that
+	// go-ethereum expects, a remote signer may use its own configuration.
+	SignTx(signer types.Signer, tx *types.Transaction) (*types.Transaction, error)
+
+	// SignHash signs a 32 byte hash. Signatures are 65 bytes [R||S||V] with
+	// V set to 27 or 28, as expected by ecrecover.
+	SignHash(hash []byte) ([]byte, error)
+
+	// SignPersonal signs a message prefixed with the Ethereum signed message
+	// prefix, in the same way as personal_sign.
+	SignPersonal(data []byte) ([]byte, error)
+}
+
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewKeySigner returns a Signer that keeps a private key in memory.
+func NewKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &keySigner{
+		key: key,
+	}
+}
+
+// NewKeystoreSigner decrypts a keystore file and returns a Signer for its key.
+// Both republic-go and go-ethereum keystore files are supported.
+func NewKeystoreSigner(path string, passphrase string) (Signer, error) {
+	json, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ks := crypto.Keystore{}
+	if err := ks.DecryptFromJSON(json, passphrase); err != nil {
+		key, err := keystore.DecryptKey(json, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeySigner(key.PrivateKey), nil
+	}
+	return NewKeySigner(ks.EcdsaKey.PrivateKey), nil
+}
+
+func (signer *keySigner) Address() common.Address {
+	return ethCrypto.PubkeyToAddress(signer.key.PublicKey)
+}
+
+func (signer *keySigner) SignTx(s types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	return types.SignTx(tx, s, signer.key)
+}
+
+func (signer *keySigner) SignHash(hash []byte) ([]byte, error) {
+	signature, err := ethCrypto.Sign(hash, signer.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+func (signer *keySigner) SignPersonal(data []byte) ([]byte, error) {
+	return signer.SignHash(PersonalHash(data))
+}
@@ -2,29 +2,28 @@ package trader
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"crypto/rand"
-	"io/ioutil"
+	"errors"
+	"fmt"
 	"math/big"
-	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/republicprotocol/renex-sdk-go/adapter/client"
-	"github.com/republicprotocol/republic-go/crypto"
+	"github.com/republicprotocol/renex-sdk-go/adapter/journal"
 )
 
 type trader struct {
-	transactOpts *bind.TransactOpts
-	address      common.Address
-	*ecdsa.PrivateKey
-	*sync.RWMutex
+	signer  Signer
+	nonces  NonceManager
+	journal journal.Journal
+
+	gasStrategy    GasStrategy
+	gasLimitMargin uint64
+	chainID        *big.Int
 }
 
 // Trader represents an individual entity that opens orders.
@@ -32,103 +31,345 @@ type trader struct {
 // or even participants with insufficient funds.
 type Trader interface {
 	Sign([]byte) ([]byte, error)
-	SendTx(f func() (client.Client, *types.Transaction, error)) (*types.Transaction, error)
+	SignHash([]byte) ([]byte, error)
+	SendTx(ctx context.Context, f func(*bind.TransactOpts) (client.Client, *types.Transaction, error)) (*types.Transaction, error)
+	SpeedUp(ctx context.Context, c client.Client, txHash common.Hash, gasPrice *big.Int) (*types.Transaction, error)
+	CancelTx(ctx context.Context, c client.Client, txHash common.Hash) (*types.Transaction, error)
 	TransactOpts() *bind.TransactOpts
 	Address() common.Address
 }
 
-func NewTrader(path string, passphrase string) (Trader, error) {
-	ks := crypto.Keystore{}
-	keyin, err := os.Open(path)
-	if err != nil {
-		return nil, err
+// An Option configures a Trader.
+type Option func(*trader)
+
+// WithGasStrategy sets the strategy that chooses the gas price of
+// transactions. It defaults to the gas price suggested by the node.
+func WithGasStrategy(strategy GasStrategy) Option {
+	return func(t *trader) {
+		t.gasStrategy = strategy
+	}
+}
+
+// WithGasLimitMargin sets the percentage added to the estimated gas limit of
+// transactions. It defaults to DefaultGasLimitMargin.
+func WithGasLimitMargin(margin uint64) Option {
+	return func(t *trader) {
+		t.gasLimitMargin = margin
+	}
+}
+
+// WithNonceManager sets the NonceManager that hands out the nonces of
+// transactions. It defaults to a NonceManager that keeps its state in memory.
+func WithNonceManager(nonces NonceManager) Option {
+	return func(t *trader) {
+		t.nonces = nonces
+	}
+}
+
+// WithJournal sets the Journal in which every transaction is recorded before
+// it is broadcast.
+func WithJournal(j journal.Journal) Option {
+	return func(t *trader) {
+		t.journal = j
+	}
+}
+
+// WithChainID sets the EIP-155 chain ID with which TransactOpts signs
+// transactions. SendTx always signs with the chain ID of the client that sends
+// the transaction. Without a chain ID, transactions are signed without replay
+// protection.
+func WithChainID(chainID *big.Int) Option {
+	return func(t *trader) {
+		t.chainID = chainID
 	}
-	json, err := ioutil.ReadAll(keyin)
+}
+
+// NewTrader returns a Trader that signs with the key of a keystore file.
+func NewTrader(path string, passphrase string, options ...Option) (Trader, error) {
+	signer, err := NewKeystoreSigner(path, passphrase)
 	if err != nil {
 		return nil, err
 	}
-	if err := ks.DecryptFromJSON(json, passphrase); err != nil {
-		key, err := keystore.DecryptKey(json, passphrase)
-		if err != nil {
-			return nil, err
-		}
-		transactOpts := bind.NewKeyedTransactor(key.PrivateKey)
-		return &trader{
-			transactOpts: transactOpts,
-			address:      transactOpts.From,
-			PrivateKey:   key.PrivateKey,
-			RWMutex:      new(sync.RWMutex),
-		}, nil
-	}
+	return NewTraderFromSigner(signer, options...), nil
+}
 
-	transactOpts := bind.NewKeyedTransactor(ks.EcdsaKey.PrivateKey)
-	return &trader{
-		transactOpts: transactOpts,
-		address:      transactOpts.From,
-		PrivateKey:   ks.EcdsaKey.PrivateKey,
-		RWMutex:      new(sync.RWMutex),
-	}, nil
+// NewTraderFromSigner returns a Trader that signs every transaction and
+// message with a Signer.
+func NewTraderFromSigner(signer Signer, options ...Option) Trader {
+	t := &trader{
+		signer:         signer,
+		nonces:         NewNonceManager(signer.Address(), nil),
+		gasStrategy:    NewSuggestedGasStrategy(),
+		gasLimitMargin: DefaultGasLimitMargin,
+	}
+	for _, option := range options {
+		option(t)
+	}
+	return t
 }
 
 func (t *trader) TransactOpts() *bind.TransactOpts {
-	return bind.NewKeyedTransactor(t.PrivateKey)
+	return t.transactOpts(t.chainID)
+}
+
+// transactOpts returns TransactOpts that sign for a chain. The signing scheme
+// chosen by bind is ignored, since it never adds replay protection.
+func (t *trader) transactOpts(chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: t.signer.Address(),
+		Signer: func(_ types.Signer, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if addr != t.signer.Address() {
+				return nil, errors.New("not authorized to sign this account")
+			}
+			return t.signer.SignTx(TxSigner(chainID), tx)
+		},
+	}
 }
 
 func (t *trader) Address() common.Address {
-	return bind.NewKeyedTransactor(t.PrivateKey).From
+	return t.signer.Address()
 }
 
+// Sign signs data prefixed with the Ethereum signed message prefix, in the
+// same way as personal_sign and as recovered by the RenEx contracts, see
+// Recover and OpenMessage.
 func (t *trader) Sign(data []byte) ([]byte, error) {
-	return t.PrivateKey.Sign(rand.Reader, data, nil)
+	return t.signer.SignPersonal(data)
 }
 
-func (t *trader) SendTx(f func() (client.Client, *types.Transaction, error)) (*types.Transaction, error) {
-	t.Lock()
-	defer t.Unlock()
-	return t.sendTx(f)
+// SignHash signs a 32 byte hash without a prefix, see RecoverHash. Remote
+// signers refuse to sign hashes and return ErrRemoteSignHash.
+func (t *trader) SignHash(hash []byte) ([]byte, error) {
+	return t.signer.SignHash(hash)
 }
 
-func (t *trader) sendTx(f func() (client.Client, *types.Transaction, error)) (*types.Transaction, error) {
-	client, tx, err := f()
-	opts := t.transactOpts
+// SendTx sends the transaction created by f, retrying on nonce errors until
+// the transaction is accepted or the context is done. The gas price is chosen
+// by the GasStrategy of the trader, the gas limit is estimated and the nonce
+// is taken from the NonceManager, so f must create the transaction with the
+// TransactOpts it is given. SendTx is safe to call concurrently, and sends
+// the transactions of an account one at a time, see NonceManager.Lock.
+//
+// When the trader has a Journal, the transaction is recorded in it before it
+// is broadcast, as a step of the operation set with journal.WithStep. A
+// transaction sent without an operation is recorded as an operation of its
+// own, which is finished once the transaction is sent.
+func (t *trader) SendTx(ctx context.Context, f func(*bind.TransactOpts) (client.Client, *types.Transaction, error)) (sent *types.Transaction, err error) {
+	opts, c, err := t.gasOpts(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	record, finish, err := t.journalOpts(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	nonce, err := client.Client().PendingNonceAt(context.Background(), t.address)
+	// A transaction that may have reached the node is left pending in the
+	// journal, so that it is waited for when the operation is resumed
+	inFlight := false
+	defer func() {
+		if inFlight {
+			return
+		}
+		if finishErr := finish(err); finishErr != nil && err == nil {
+			err = finishErr
+		}
+	}()
+
+	// The nonces stay locked until the transaction is sent, so that no other
+	// sender is handed the same nonce
+	unlock, err := t.nonces.Lock(ctx)
 	if err != nil {
-		return tx, err
+		return nil, err
 	}
-	opts.Nonce = big.NewInt(int64(nonce))
-	opts.GasLimit = 3000000
+	defer unlock()
 
-	if err == nil {
-		opts.Nonce.Add(opts.Nonce, big.NewInt(1))
-		return tx, nil
+	// Keep the last signed transaction, since none is returned when sending
+	// fails
+	var signed *types.Transaction
+	signer := opts.Signer
+	opts.Signer = func(s types.Signer, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		tx, err := signer(s, addr, tx)
+		if err == nil {
+			signed = tx
+		}
+		return tx, err
 	}
 
-	if err == core.ErrNonceTooLow || err == core.ErrReplaceUnderpriced || strings.Contains(err.Error(), "nonce is too low") {
-		opts.Nonce.Add(opts.Nonce, big.NewInt(1))
-		return t.sendTx(f)
+	// If a nonce error occurs the next nonce is reconciled with the chain and
+	// we try again for up to 1 minute
+	for try := 0; ; try++ {
+		nonce, err := t.nonces.Next(ctx, c.Client())
+		if err != nil {
+			return nil, err
+		}
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+
+		signed = nil
+		_, tx, err := f(opts)
+		if err == nil {
+			return tx, record(tx, journal.StateSent)
+		}
+		if signed != nil && client.IsKnownTransaction(err) {
+			// An earlier attempt reached the node
+			return signed, record(signed, journal.StateSent)
+		}
+		if signed != nil && !isRejected(err) {
+			// The transaction may have reached the node, so its nonce is
+			// not released and the next call to Next reconciles it with
+			// the chain
+			inFlight = true
+			return nil, err
+		}
+
+		if recordErr := record(tx, journal.StateFailed); recordErr != nil {
+			return nil, recordErr
+		}
+		if releaseErr := t.nonces.Release(nonce); releaseErr != nil {
+			return nil, releaseErr
+		}
+		if !isNonceError(err) || try >= 60 {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
 	}
+}
 
-	if err == core.ErrNonceTooHigh {
-		opts.Nonce.Sub(opts.Nonce, big.NewInt(1))
-		return t.sendTx(f)
+// journalOpts wraps the signer of opts so that every signed transaction is
+// recorded in the journal before it is broadcast. It returns a function that
+// records a new state for a transaction, and a function that finishes the
+// operation when it was begun for this transaction alone.
+func (t *trader) journalOpts(ctx context.Context, opts *bind.TransactOpts) (func(*types.Transaction, journal.State) error, func(error) error, error) {
+	noFinish := func(error) error { return nil }
+	if t.journal == nil {
+		return func(*types.Transaction, journal.State) error { return nil }, noFinish, nil
+	}
+	id, step, ok := journal.StepFromContext(ctx)
+	finish := noFinish
+	if !ok {
+		var err error
+		if id, err = t.journal.Begin(journal.KindTransaction, 0, nil); err != nil {
+			return nil, nil, err
+		}
+		step = string(journal.KindTransaction)
+		finish = func(err error) error {
+			return t.journal.Finish(id, err)
+		}
 	}
 
-	// If any other type of nonce error occurs we will refresh the nonce and
-	// try again for up to 1 minute
-	for try := 0; try < 60 && strings.Contains(err.Error(), "nonce"); try++ {
-		time.Sleep(time.Second)
-		nonce, err = client.Client().PendingNonceAt(context.Background(), opts.From)
+	// The last signed transaction is recorded when sending fails, since no
+	// transaction is returned
+	var last *types.Transaction
+	record := func(tx *types.Transaction, state journal.State) error {
+		if tx == nil {
+			tx = last
+		}
+		if tx == nil {
+			return nil
+		}
+		return t.journal.Record(id, journal.Entry{
+			Step:  step,
+			Hash:  tx.Hash(),
+			Nonce: tx.Nonce(),
+			State: state,
+		})
+	}
+	signer := opts.Signer
+	opts.Signer = func(s types.Signer, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		signed, err := signer(s, addr, tx)
 		if err != nil {
-			continue
+			return nil, err
 		}
-		opts.Nonce = big.NewInt(int64(nonce))
-		if _, tx, err = f(); err == nil {
-			opts.Nonce.Add(opts.Nonce, big.NewInt(1))
-			return tx, nil
+		if err := record(signed, journal.StateSigned); err != nil {
+			return nil, err
 		}
+		last = signed
+		return signed, nil
 	}
-	t.transactOpts.Nonce = opts.Nonce
+	return record, finish, nil
+}
+
+// errDryRun is returned by the signer of a dry run to stop the transaction
+// from being sent.
+var errDryRun = errors.New("dry run")
+
+// gasOpts returns the TransactOpts with which f should be called. The
+// transaction is created once without being sent, to find the client that
+// sends it and the gas that it uses.
+func (t *trader) gasOpts(ctx context.Context, f func(*bind.TransactOpts) (client.Client, *types.Transaction, error)) (*bind.TransactOpts, client.Client, error) {
+	opts := t.TransactOpts()
+	opts.Context = ctx
+
+	var estimated *types.Transaction
+	dryRun := *opts
+	dryRun.GasPrice = big.NewInt(0)
+	dryRun.Signer = func(signer types.Signer, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		estimated = tx
+		return nil, errDryRun
+	}
+	client, _, err := f(&dryRun)
+	if err != errDryRun {
+		if err == nil {
+			err = fmt.Errorf("cannot estimate gas: transaction was not signed")
+		}
+		return nil, nil, err
+	}
+
+	price, err := t.gasStrategy.GasPrice(ctx, client.Client())
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.GasPrice = price
+	opts.GasLimit = estimated.Gas() + estimated.Gas()*t.gasLimitMargin/100
+	if chainID := client.ChainID(); chainID != nil {
+		opts.Signer = t.transactOpts(chainID).Signer
+	}
+	return opts, client, nil
+}
+
+// TxSigner returns the signing scheme for transactions on a chain.
+// Transactions are signed without replay protection when there is no chain
+// ID.
+func TxSigner(chainID *big.Int) types.Signer {
+	if chainID == nil || chainID.Sign() == 0 {
+		return types.HomesteadSigner{}
+	}
+	return types.NewEIP155Signer(chainID)
+}
+
+// rejectionErrors are the errors with which a node rejects a transaction
+// without keeping it.
+var rejectionErrors = []string{
+	"nonce too low",
+	"nonce too high",
+	"replacement transaction underpriced",
+	"transaction underpriced",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"exceeds block gas limit",
+	"oversized data",
+	"negative value",
+	"invalid sender",
+}
+
+// isRejected returns true if the error proves that a node did not accept a
+// transaction. Other errors, like timeouts, can be returned for transactions
+// that reached the node.
+func isRejected(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, rejection := range rejectionErrors {
+		if strings.Contains(msg, rejection) {
+			return true
+		}
+	}
+	return false
+}
 
-	return tx, err
+func isNonceError(err error) bool {
+	return err == core.ErrNonceTooLow || err == core.ErrNonceTooHigh || err == core.ErrReplaceUnderpriced || strings.Contains(err.Error(), "nonce")
 }
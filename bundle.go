@@ -0,0 +1,58 @@
+package renex
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/republicprotocol/renex-ingress-go/httpadapter"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/offline"
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// PrepareDeposit returns a bundle of unsigned transactions that deposit a
+// token into RenEx, see offline.Builder.Deposit. The bundle can be signed
+// offline with offline.SignWithKeystore and sent with BroadcastBundle.
+func (renex RenEx) PrepareDeposit(ctx context.Context, token order.Token, value *big.Int) (offline.Bundle, error) {
+	builder, err := offline.NewBuilder(renex.client, renex.trader.Address(), nil)
+	if err != nil {
+		return offline.Bundle{}, err
+	}
+	return builder.Deposit(ctx, token, value)
+}
+
+// PrepareWithdraw returns a bundle of unsigned transactions that withdraw a
+// token from RenEx with the approval of the ingress. The approval is only
+// valid until the next withdrawal of the trader, so the bundle must be signed
+// and broadcast before withdrawing again, see offline.Builder.Withdraw.
+func (renex RenEx) PrepareWithdraw(ctx context.Context, token order.Token, value *big.Int) (offline.Bundle, error) {
+	signature, err := renex.ingress.ApproveWithdrawal(ctx, httpadapter.ApproveWithdrawalRequest{
+		Trader:  renex.trader.Address().String()[2:],
+		TokenID: uint32(token),
+	})
+	if err != nil {
+		return offline.Bundle{}, err
+	}
+	builder, err := offline.NewBuilder(renex.client, renex.trader.Address(), nil)
+	if err != nil {
+		return offline.Bundle{}, err
+	}
+	return builder.Withdraw(ctx, token, value, signature)
+}
+
+// PrepareTransfer returns a bundle of unsigned transactions that transfer
+// ether or a token to an address.
+func (renex RenEx) PrepareTransfer(ctx context.Context, address string, token order.Token, value *big.Int) (offline.Bundle, error) {
+	builder, err := offline.NewBuilder(renex.client, renex.trader.Address(), nil)
+	if err != nil {
+		return offline.Bundle{}, err
+	}
+	return builder.Transfer(ctx, common.HexToAddress(address), token, value)
+}
+
+// BroadcastBundle sends the transactions of a signed bundle and waits for
+// them to be confirmed, see offline.Broadcast.
+func (renex RenEx) BroadcastBundle(ctx context.Context, bundle offline.Bundle) ([]client.Confirmation, error) {
+	return offline.Broadcast(ctx, renex.client, bundle)
+}
@@ -0,0 +1,45 @@
+package funds
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// ErrUnregisteredToken is returned when a token is not registered with the
+// RenExTokens contract.
+var ErrUnregisteredToken = errors.New("Unregistered token")
+
+// ErrUnsupportedCurrency is returned when a token cannot be transferred or
+// queried by the SDK.
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// ErrTransactionPending is returned by Withdraw when the transaction that
+// signalled a fail safe withdrawal has not been mined.
+var ErrTransactionPending = errors.New("Transaction Pending")
+
+// ErrWithdrawalTimeLocked is returned by Withdraw when the fail safe
+// withdrawal has been signalled but cannot be triggered yet.
+var ErrWithdrawalTimeLocked = errors.New("Time left to trigger withdrawal without signature")
+
+// ErrCorruptedIdempotentKey is returned by Withdraw when the idempotent key
+// does not refer to a fail safe withdrawal signal.
+var ErrCorruptedIdempotentKey = errors.New("Corrupted Idempotent Key")
+
+// ErrNilTransaction is returned when a transaction was accepted without
+// error but no transaction was returned.
+var ErrNilTransaction = errors.New("Nil Transaction")
+
+// InsufficientBalanceError is returned when an order or withdrawal needs more
+// of a token than the usable balance of the trader.
+type InsufficientBalanceError struct {
+	Token order.Token
+	Have  *big.Int
+	Want  *big.Int
+}
+
+func (err InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("[%v] Order volume exceeded usable balance have:%v want:%v", err.Token, err.Have, err.Want)
+}
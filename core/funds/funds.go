@@ -1,7 +1,7 @@
 package funds
 
 import (
-	"fmt"
+	"context"
 	"math/big"
 
 	"github.com/republicprotocol/republic-go/order"
@@ -15,29 +15,37 @@ type service struct {
 
 type Adapter interface {
 	Address() string
-	BalanceEth() (*big.Int, error)
-	BalanceErc20(tokenCode order.Token) (*big.Int, error)
-	RenExBalance(tokenCode order.Token) (*big.Int, error)
-	TransferEth(address string, value *big.Int) error
-	TransferERC20(address string, token order.Token, value *big.Int) error
+	BalanceEth(ctx context.Context) (*big.Int, error)
+	BalanceErc20(ctx context.Context, tokenCode order.Token) (*big.Int, error)
+	RenExBalance(ctx context.Context, tokenCode order.Token) (*big.Int, error)
+	TransferEth(ctx context.Context, address string, value *big.Int) error
+	TransferERC20(ctx context.Context, address string, token order.Token, value *big.Int) error
 	RequestLockedBalance(tokenCode order.Token) (*big.Int, error)
-	RequestDeposit(tokenCode order.Token, value *big.Int) error
-	RequestWithdrawalSignature(tokenCode order.Token, value *big.Int) ([]byte, error)
-	RequestWithdrawalWithSignature(tokenCode order.Token, value *big.Int, signature []byte) error
-	RequestWithdrawalFailSafe(tokenCode order.Token, value *big.Int) error
-	RequestWithdrawalFailSafeTrigger(tokenCode order.Token) (*IdempotentKey, error)
+	RequestDeposit(ctx context.Context, tokenCode order.Token, value *big.Int) error
+	RequestWithdrawalSignature(ctx context.Context, tokenCode order.Token, value *big.Int) ([]byte, error)
+	RequestWithdrawalWithSignature(ctx context.Context, tokenCode order.Token, value *big.Int, signature []byte) error
+	RequestWithdrawalFailSafe(ctx context.Context, tokenCode order.Token, value *big.Int) error
+	RequestWithdrawalFailSafeTrigger(ctx context.Context, tokenCode order.Token) (*IdempotentKey, error)
 	OpenOrdersExist(tokenCode order.Token) (bool, error)
-	CheckStatus(key *IdempotentKey) uint8
+	CheckStatus(ctx context.Context, key *IdempotentKey) uint8
 }
 
+// Funds manages the balances of a trader. Every method has a Context variant
+// that stops waiting for the network when the context is done.
 type Funds interface {
 	Address() string
 	Transfer(address string, token order.Token, value *big.Int) error
+	TransferContext(ctx context.Context, address string, token order.Token, value *big.Int) error
 	Balance(token order.Token) (*big.Int, error)
+	BalanceContext(ctx context.Context, token order.Token) (*big.Int, error)
 	RenExBalance(token order.Token) (*big.Int, error)
+	RenExBalanceContext(ctx context.Context, token order.Token) (*big.Int, error)
 	UsableRenExBalance(token order.Token) (*big.Int, error)
+	UsableRenExBalanceContext(ctx context.Context, token order.Token) (*big.Int, error)
 	Deposit(token order.Token, value *big.Int) error
+	DepositContext(ctx context.Context, token order.Token, value *big.Int) error
 	Withdraw(token order.Token, value *big.Int, forced bool, key *IdempotentKey) (*IdempotentKey, error)
+	WithdrawContext(ctx context.Context, token order.Token, value *big.Int, forced bool, key *IdempotentKey) (*IdempotentKey, error)
 }
 
 func NewService(adapter Adapter) Funds {
@@ -47,37 +55,57 @@ func NewService(adapter Adapter) Funds {
 }
 
 func (service *service) Withdraw(token order.Token, value *big.Int, forced bool, key *IdempotentKey) (*IdempotentKey, error) {
-	switch service.CheckStatus(key) {
+	return service.WithdrawContext(context.Background(), token, value, forced, key)
+}
+
+func (service *service) WithdrawContext(ctx context.Context, token order.Token, value *big.Int, forced bool, key *IdempotentKey) (*IdempotentKey, error) {
+	switch service.CheckStatus(ctx, key) {
 	case 0:
-		sig, err := service.RequestWithdrawalSignature(token, value)
+		sig, err := service.RequestWithdrawalSignature(ctx, token, value)
 		if err != nil {
 			if !forced {
 				return nil, err
 			}
-			failSafeKey, err := service.RequestWithdrawalFailSafeTrigger(token)
+			failSafeKey, err := service.RequestWithdrawalFailSafeTrigger(ctx, token)
 			if err != nil {
 				return nil, err
 			}
 			return failSafeKey, nil
 		}
-		return nil, service.RequestWithdrawalWithSignature(token, value, sig)
+		return nil, service.RequestWithdrawalWithSignature(ctx, token, value, sig)
 	case 1:
-		return key, fmt.Errorf("Transaction Pending")
+		return key, ErrTransactionPending
 	case 2:
-		return key, fmt.Errorf("Time left to trigger withdrawal without signature")
+		return key, ErrWithdrawalTimeLocked
 	case 3:
-		return key, service.RequestWithdrawalFailSafe(token, value)
+		return key, service.RequestWithdrawalFailSafe(ctx, token, value)
 	default:
-		return key, fmt.Errorf("Corrupted Idempotent Key")
+		return key, ErrCorruptedIdempotentKey
 	}
 }
 
 func (service *service) Deposit(tokenCode order.Token, value *big.Int) error {
-	return service.RequestDeposit(tokenCode, value)
+	return service.DepositContext(context.Background(), tokenCode, value)
+}
+
+func (service *service) DepositContext(ctx context.Context, tokenCode order.Token, value *big.Int) error {
+	return service.RequestDeposit(ctx, tokenCode, value)
+}
+
+func (service *service) RenExBalance(tokenCode order.Token) (*big.Int, error) {
+	return service.RenExBalanceContext(context.Background(), tokenCode)
+}
+
+func (service *service) RenExBalanceContext(ctx context.Context, tokenCode order.Token) (*big.Int, error) {
+	return service.Adapter.RenExBalance(ctx, tokenCode)
 }
 
 func (service *service) UsableRenExBalance(tokenCode order.Token) (*big.Int, error) {
-	balance, err := service.RenExBalance(tokenCode)
+	return service.UsableRenExBalanceContext(context.Background(), tokenCode)
+}
+
+func (service *service) UsableRenExBalanceContext(ctx context.Context, tokenCode order.Token) (*big.Int, error) {
+	balance, err := service.Adapter.RenExBalance(ctx, tokenCode)
 	if err != nil {
 		return nil, err
 	}
@@ -91,39 +119,47 @@ func (service *service) UsableRenExBalance(tokenCode order.Token) (*big.Int, err
 }
 
 func (service *service) Transfer(address string, tokenCode order.Token, value *big.Int) error {
+	return service.TransferContext(context.Background(), address, tokenCode, value)
+}
+
+func (service *service) TransferContext(ctx context.Context, address string, tokenCode order.Token, value *big.Int) error {
 	switch tokenCode {
 	case order.TokenREN:
-		return service.TransferERC20(address, tokenCode, value)
+		return service.TransferERC20(ctx, address, tokenCode, value)
 	case order.TokenDGX:
-		return service.TransferERC20(address, tokenCode, value)
+		return service.TransferERC20(ctx, address, tokenCode, value)
 	case order.TokenTUSD:
-		return service.TransferERC20(address, tokenCode, value)
+		return service.TransferERC20(ctx, address, tokenCode, value)
 	case order.TokenZRX:
-		return service.TransferERC20(address, tokenCode, value)
+		return service.TransferERC20(ctx, address, tokenCode, value)
 	case order.TokenOMG:
-		return service.TransferERC20(address, tokenCode, value)
+		return service.TransferERC20(ctx, address, tokenCode, value)
 	case order.TokenETH:
-		return service.TransferEth(address, value)
+		return service.TransferEth(ctx, address, value)
 	default:
-		return fmt.Errorf("unsupported currency")
+		return ErrUnsupportedCurrency
 	}
 }
 
 func (service *service) Balance(tokenCode order.Token) (*big.Int, error) {
+	return service.BalanceContext(context.Background(), tokenCode)
+}
+
+func (service *service) BalanceContext(ctx context.Context, tokenCode order.Token) (*big.Int, error) {
 	switch tokenCode {
 	case order.TokenREN:
-		return service.BalanceErc20(tokenCode)
+		return service.BalanceErc20(ctx, tokenCode)
 	case order.TokenDGX:
-		return service.BalanceErc20(tokenCode)
+		return service.BalanceErc20(ctx, tokenCode)
 	case order.TokenTUSD:
-		return service.BalanceErc20(tokenCode)
+		return service.BalanceErc20(ctx, tokenCode)
 	case order.TokenZRX:
-		return service.BalanceErc20(tokenCode)
+		return service.BalanceErc20(ctx, tokenCode)
 	case order.TokenOMG:
-		return service.BalanceErc20(tokenCode)
+		return service.BalanceErc20(ctx, tokenCode)
 	case order.TokenETH:
-		return service.BalanceEth()
+		return service.BalanceEth(ctx)
 	default:
-		return nil, fmt.Errorf("unsupported currency")
+		return nil, ErrUnsupportedCurrency
 	}
 }
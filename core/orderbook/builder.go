@@ -0,0 +1,390 @@
+package orderbook
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// Decimals is the number of decimals of the fixed point prices and volumes of
+// an order.Order.
+const Decimals = 12
+
+// DefaultExpiry is the time for which an order is open when no expiry is set.
+const DefaultExpiry = 24 * time.Hour
+
+// ErrInvalidPair is returned when a pair is not two known tokens separated by
+// a slash, with the priority token first.
+var ErrInvalidPair = errors.New("invalid token pair")
+
+// ErrInvalidPrice is returned when a price is missing, not positive or out of
+// range.
+var ErrInvalidPrice = errors.New("invalid price")
+
+// ErrInvalidVolume is returned when a volume is missing, not positive or out
+// of range.
+var ErrInvalidVolume = errors.New("invalid volume")
+
+// ErrInvalidMinimumVolume is returned when the minimum fill of an order is
+// negative or larger than its volume.
+var ErrInvalidMinimumVolume = errors.New("invalid minimum volume")
+
+// ErrInvalidExpiry is returned when an order expires in the past.
+var ErrInvalidExpiry = errors.New("invalid expiry")
+
+// PrecisionError is returned when a price or volume cannot be represented
+// exactly by an order. Nearest is the closest value that can be.
+type PrecisionError struct {
+	Field   string
+	Value   string
+	Nearest string
+}
+
+func (err PrecisionError) Error() string {
+	return fmt.Sprintf("cannot represent %s %s without loss of precision, nearest is %s", err.Field, err.Value, err.Nearest)
+}
+
+// Side is the side of the book on which an order is opened.
+type Side int
+
+// Values for Side. A buy order on ETH/REN buys REN with ETH.
+const (
+	Buy Side = iota
+	Sell
+)
+
+// TimeInForce is how long an order stays open.
+type TimeInForce int
+
+// Values for TimeInForce. GoodTillExpiry orders stay open until they are
+// matched, cancelled or expire. ImmediateOrCancel orders are cancelled if they
+// are not matched when they are first considered.
+const (
+	GoodTillExpiry TimeInForce = iota
+	ImmediateOrCancel
+)
+
+var tokens = map[string]order.Token{
+	"BTC":  order.TokenBTC,
+	"ETH":  order.TokenETH,
+	"DGX":  order.TokenDGX,
+	"TUSD": order.TokenTUSD,
+	"REN":  order.TokenREN,
+	"ZRX":  order.TokenZRX,
+	"OMG":  order.TokenOMG,
+}
+
+// OrderBuilder builds an order.Order from human readable values. Prices are
+// in units of the priority token per unit of the other token, and volumes
+// are in units of the other token. Every method records the first error,
+// which is returned by Build.
+type OrderBuilder struct {
+	pair          order.Tokens
+	side          Side
+	price         *big.Rat
+	volume        *big.Rat
+	minimumVolume *big.Rat
+	timeInForce   TimeInForce
+	midpoint      bool
+	expiry        time.Time
+	nonce         *uint64
+	err           error
+}
+
+// NewOrderBuilder returns an OrderBuilder for a pair, such as "ETH/REN", and
+// a side.
+func NewOrderBuilder(pair string, side Side) *OrderBuilder {
+	builder := &OrderBuilder{side: side}
+	builder.pair, builder.err = ParsePair(pair)
+	if builder.err == nil && side != Buy && side != Sell {
+		builder.err = fmt.Errorf("invalid side %d", side)
+	}
+	return builder
+}
+
+// ParsePair returns the order.Tokens of a pair such as "ETH/REN".
+func ParsePair(pair string) (order.Tokens, error) {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(pair)), "/")
+	if len(parts) != 2 {
+		return 0, ErrInvalidPair
+	}
+	priority, ok := tokens[strings.TrimSpace(parts[0])]
+	if !ok {
+		return 0, ErrInvalidPair
+	}
+	nonPriority, ok := tokens[strings.TrimSpace(parts[1])]
+	if !ok || priority >= nonPriority {
+		return 0, ErrInvalidPair
+	}
+	return order.Tokens(uint64(priority)<<32 | uint64(nonPriority)), nil
+}
+
+// Price sets the limit price as a decimal string.
+func (builder *OrderBuilder) Price(price string) *OrderBuilder {
+	builder.price = builder.parse(price, ErrInvalidPrice)
+	return builder
+}
+
+// Midpoint opens the order at the midpoint price of the market instead of a
+// limit price. The price is still used as the worst acceptable price.
+func (builder *OrderBuilder) Midpoint() *OrderBuilder {
+	builder.midpoint = true
+	return builder
+}
+
+// Volume sets the volume as a decimal string.
+func (builder *OrderBuilder) Volume(volume string) *OrderBuilder {
+	builder.volume = builder.parse(volume, ErrInvalidVolume)
+	return builder
+}
+
+// MinFill sets the minimum volume of a match as a decimal string. It defaults
+// to zero.
+func (builder *OrderBuilder) MinFill(volume string) *OrderBuilder {
+	builder.minimumVolume = builder.parse(volume, ErrInvalidMinimumVolume)
+	return builder
+}
+
+// TimeInForce sets how long the order stays open.
+func (builder *OrderBuilder) TimeInForce(timeInForce TimeInForce) *OrderBuilder {
+	if builder.err == nil && timeInForce != GoodTillExpiry && timeInForce != ImmediateOrCancel {
+		builder.err = fmt.Errorf("invalid time in force %d", timeInForce)
+	}
+	builder.timeInForce = timeInForce
+	return builder
+}
+
+// Expiry sets the time at which the order expires. It defaults to
+// DefaultExpiry from the time the order is built.
+func (builder *OrderBuilder) Expiry(expiry time.Time) *OrderBuilder {
+	builder.expiry = expiry
+	return builder
+}
+
+// Nonce sets the nonce of the order. It defaults to a random nonce, which
+// gives identical orders different IDs.
+func (builder *OrderBuilder) Nonce(nonce uint64) *OrderBuilder {
+	builder.nonce = &nonce
+	return builder
+}
+
+// Build validates the order and returns it.
+func (builder *OrderBuilder) Build() (order.Order, error) {
+	if builder.err != nil {
+		return order.Order{}, builder.err
+	}
+	if builder.price == nil {
+		return order.Order{}, ErrInvalidPrice
+	}
+	if builder.volume == nil {
+		return order.Order{}, ErrInvalidVolume
+	}
+
+	price, err := fixedPoint("price", builder.price, priceEncoding)
+	if err != nil {
+		return order.Order{}, err
+	}
+	if price == 0 {
+		return order.Order{}, ErrInvalidPrice
+	}
+	volume, err := fixedPoint("volume", builder.volume, volumeEncoding)
+	if err != nil {
+		return order.Order{}, err
+	}
+	if volume == 0 {
+		return order.Order{}, ErrInvalidVolume
+	}
+	minimumVolume := uint64(0)
+	if builder.minimumVolume != nil {
+		if minimumVolume, err = fixedPoint("minimum volume", builder.minimumVolume, volumeEncoding); err != nil {
+			return order.Order{}, err
+		}
+		if minimumVolume > volume {
+			return order.Order{}, ErrInvalidMinimumVolume
+		}
+	}
+
+	expiry := builder.expiry
+	if expiry.IsZero() {
+		expiry = time.Now().Add(DefaultExpiry)
+	}
+	if !expiry.After(time.Now()) {
+		return order.Order{}, ErrInvalidExpiry
+	}
+
+	nonce := uint64(0)
+	if builder.nonce != nil {
+		nonce = *builder.nonce
+	} else {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return order.Order{}, err
+		}
+		nonce = binary.BigEndian.Uint64(b)
+	}
+
+	parity := order.ParityBuy
+	if builder.side == Sell {
+		parity = order.ParitySell
+	}
+	settlement := order.SettlementRenEx
+	if builder.pair.PriorityToken() == order.TokenBTC {
+		settlement = order.SettlementRenExAtomic
+	}
+	return order.NewOrder(parity, builder.orderType(), expiry, settlement, builder.pair, price, volume, minimumVolume, nonce), nil
+}
+
+// ValidateOrder returns an error if an order cannot be opened, such as an
+// order without volume, an expired order or an order with a price or volume
+// that darknodes cannot represent exactly.
+func ValidateOrder(ord order.Order) error {
+	priority, nonPriority := ord.Tokens.PriorityToken(), ord.Tokens.NonPriorityToken()
+	if !knownToken(priority) || !knownToken(nonPriority) || priority >= nonPriority {
+		return ErrInvalidPair
+	}
+	if ord.Price == 0 {
+		return ErrInvalidPrice
+	}
+	if ord.Volume == 0 {
+		return ErrInvalidVolume
+	}
+	if ord.MinimumVolume > ord.Volume {
+		return ErrInvalidMinimumVolume
+	}
+	if !ord.Expiry.After(time.Now()) {
+		return ErrInvalidExpiry
+	}
+
+	scale := pow10(Decimals)
+	if _, err := fixedPoint("price", new(big.Rat).SetFrac(new(big.Int).SetUint64(ord.Price), scale), priceEncoding); err != nil {
+		return err
+	}
+	if _, err := fixedPoint("volume", new(big.Rat).SetFrac(new(big.Int).SetUint64(ord.Volume), scale), volumeEncoding); err != nil {
+		return err
+	}
+	_, err := fixedPoint("minimum volume", new(big.Rat).SetFrac(new(big.Int).SetUint64(ord.MinimumVolume), scale), volumeEncoding)
+	return err
+}
+
+func knownToken(token order.Token) bool {
+	for _, code := range tokens {
+		if code == token {
+			return true
+		}
+	}
+	return false
+}
+
+func (builder *OrderBuilder) orderType() order.Type {
+	switch {
+	case builder.midpoint && builder.timeInForce == ImmediateOrCancel:
+		return order.TypeMidpointIOC
+	case builder.midpoint:
+		return order.TypeMidpoint
+	case builder.timeInForce == ImmediateOrCancel:
+		return order.TypeLimitIOC
+	default:
+		return order.TypeLimit
+	}
+}
+
+func (builder *OrderBuilder) parse(value string, invalid error) *big.Rat {
+	if builder.err != nil {
+		return nil
+	}
+	rat, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok || rat.Sign() < 0 {
+		builder.err = invalid
+		return nil
+	}
+	return rat
+}
+
+// encoding is the coefficient and exponent representation used by darknodes
+// for the prices and volumes of orders. A positive value is represented as
+// co × step × 10^(exp - offset), with co in [1, maxCo] and exp in [0, 52].
+type encoding struct {
+	step   *big.Rat
+	maxCo  int64
+	offset int64
+}
+
+// Prices are represented as co × 0.005 × 10^(exp - 26) with co below 2000,
+// and volumes as co × 0.2 × 10^(exp - 12) with co below 50.
+var (
+	priceEncoding  = encoding{step: big.NewRat(5, 1000), maxCo: 1999, offset: 26}
+	volumeEncoding = encoding{step: big.NewRat(2, 10), maxCo: 49, offset: 12}
+)
+
+// coExp returns the coefficient and exponent that represent a positive value,
+// rounding the coefficient down, and whether the representation is exact. It
+// returns false if the value is out of range.
+func (enc encoding) coExp(value *big.Rat) (uint64, uint64, bool, bool) {
+	ten := big.NewRat(10, 1)
+	upper := new(big.Rat).Mul(enc.step, big.NewRat(enc.maxCo+1, 1))
+
+	// Normalize the value into [step, step × (maxCo + 1))
+	q := new(big.Rat).Set(value)
+	exp := enc.offset
+	for q.Cmp(upper) >= 0 {
+		q.Quo(q, ten)
+		exp++
+	}
+	for q.Cmp(enc.step) < 0 {
+		q.Mul(q, ten)
+		exp--
+	}
+	if exp < 0 || exp > 52 {
+		return 0, 0, false, false
+	}
+
+	co := new(big.Rat).Quo(q, enc.step)
+	rounded := new(big.Int).Quo(co.Num(), co.Denom())
+	return rounded.Uint64(), uint64(exp), co.IsInt(), true
+}
+
+// value returns the value of a coefficient and exponent.
+func (enc encoding) value(co, exp uint64) *big.Rat {
+	value := new(big.Rat).Mul(enc.step, new(big.Rat).SetInt64(int64(co)))
+	shift := int64(exp) - enc.offset
+	if shift < 0 {
+		return value.Quo(value, new(big.Rat).SetInt(pow10(-shift)))
+	}
+	return value.Mul(value, new(big.Rat).SetInt(pow10(shift)))
+}
+
+// fixedPoint converts a decimal value to the fixed point value of an order,
+// and checks that darknodes can represent it without loss of precision.
+func fixedPoint(field string, value *big.Rat, enc encoding) (uint64, error) {
+	if value.Sign() == 0 {
+		return 0, nil
+	}
+
+	co, exp, exact, ok := enc.coExp(value)
+	if !ok {
+		return 0, fmt.Errorf("%s %s is out of range", field, value.FloatString(Decimals))
+	}
+	if !exact {
+		return 0, PrecisionError{Field: field, Value: value.RatString(), Nearest: enc.value(co, exp).RatString()}
+	}
+
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(pow10(Decimals)))
+	if !scaled.IsInt() {
+		nearest := new(big.Rat).SetFrac(new(big.Int).Quo(scaled.Num(), scaled.Denom()), pow10(Decimals))
+		return 0, PrecisionError{Field: field, Value: value.RatString(), Nearest: nearest.RatString()}
+	}
+	if !scaled.Num().IsUint64() {
+		return 0, fmt.Errorf("%s %s is out of range", field, value.FloatString(Decimals))
+	}
+	return scaled.Num().Uint64(), nil
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
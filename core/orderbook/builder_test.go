@@ -0,0 +1,123 @@
+package orderbook
+
+import (
+	"math/big"
+	"testing"
+)
+
+func rat(t *testing.T, value string) *big.Rat {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		t.Fatalf("cannot parse %s", value)
+	}
+	return r
+}
+
+func TestCoExp(t *testing.T) {
+	tests := []struct {
+		name  string
+		enc   encoding
+		value string
+		co    uint64
+		exp   uint64
+		exact bool
+		ok    bool
+	}{
+		{"price one", priceEncoding, "1", 200, 26, true, true},
+		{"price step", priceEncoding, "0.005", 1, 26, true, true},
+		{"price largest coefficient", priceEncoding, "9.995", 1999, 26, true, true},
+		{"price next exponent", priceEncoding, "10", 200, 27, true, true},
+		{"price below step", priceEncoding, "0.0001", 2, 24, true, true},
+		{"price rounded down", priceEncoding, "1.2345", 246, 26, false, true},
+		{"price between coefficients", priceEncoding, "9.9999", 1999, 26, false, true},
+		{"price largest exponent", priceEncoding, "1e26", 200, 52, true, true},
+		{"price smallest exponent", priceEncoding, "5e-29", 1, 0, true, true},
+		{"price above range", priceEncoding, "1e27", 0, 0, false, false},
+		{"price below range", priceEncoding, "4e-29", 0, 0, false, false},
+		{"volume one", volumeEncoding, "1", 5, 12, true, true},
+		{"volume step", volumeEncoding, "0.2", 1, 12, true, true},
+		{"volume largest coefficient", volumeEncoding, "9.8", 49, 12, true, true},
+		{"volume next exponent", volumeEncoding, "10", 5, 13, true, true},
+		{"volume rounded down", volumeEncoding, "0.5", 2, 12, false, true},
+		{"volume largest exponent", volumeEncoding, "9.8e40", 49, 52, true, true},
+		{"volume above range", volumeEncoding, "1e41", 0, 0, false, false},
+		{"volume below range", volumeEncoding, "1e-13", 0, 0, false, false},
+	}
+	for _, test := range tests {
+		co, exp, exact, ok := test.enc.coExp(rat(t, test.value))
+		if co != test.co || exp != test.exp || exact != test.exact || ok != test.ok {
+			t.Errorf("%s: got (%d, %d, %v, %v), want (%d, %d, %v, %v)", test.name, co, exp, exact, ok, test.co, test.exp, test.exact, test.ok)
+		}
+	}
+}
+
+func TestEncodingValue(t *testing.T) {
+	tests := []struct {
+		enc   encoding
+		co    uint64
+		exp   uint64
+		value string
+	}{
+		{priceEncoding, 200, 26, "1"},
+		{priceEncoding, 246, 26, "123/100"},
+		{priceEncoding, 1, 0, "1/20000000000000000000000000000"},
+		{priceEncoding, 200, 52, "100000000000000000000000000"},
+		{volumeEncoding, 5, 12, "1"},
+		{volumeEncoding, 49, 13, "98"},
+	}
+	for _, test := range tests {
+		if value := test.enc.value(test.co, test.exp).RatString(); value != test.value {
+			t.Errorf("value(%d, %d): got %s, want %s", test.co, test.exp, value, test.value)
+		}
+	}
+}
+
+func TestFixedPoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		enc     encoding
+		value   string
+		want    uint64
+		nearest string
+		err     bool
+	}{
+		{"zero", priceEncoding, "0", 0, "", false},
+		{"one", priceEncoding, "1", 1000000000000, "", false},
+		{"fraction", priceEncoding, "0.125", 125000000000, "", false},
+		{"smallest decimal", priceEncoding, "0.000000000005", 5, "", false},
+		{"large volume", volumeEncoding, "9800000", 9800000000000000000, "", false},
+		{"coefficient rounded", priceEncoding, "1.2345", 0, "123/100", true},
+		{"decimals truncated", priceEncoding, "0.0000000000001", 0, "0", true},
+		{"exponent out of range", priceEncoding, "1e27", 0, "", true},
+		{"fixed point out of range", volumeEncoding, "9.8e40", 0, "", true},
+	}
+	for _, test := range tests {
+		got, err := fixedPoint("price", rat(t, test.value), test.enc)
+		if !test.err {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			} else if got != test.want {
+				t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error, got %d", test.name, got)
+			continue
+		}
+		precisionErr, isPrecisionErr := err.(PrecisionError)
+		if test.nearest == "" {
+			if isPrecisionErr {
+				t.Errorf("%s: expected a range error, got %v", test.name, err)
+			}
+			continue
+		}
+		if !isPrecisionErr {
+			t.Errorf("%s: expected a PrecisionError, got %v", test.name, err)
+			continue
+		}
+		if precisionErr.Nearest != test.nearest {
+			t.Errorf("%s: got nearest %s, want %s", test.name, precisionErr.Nearest, test.nearest)
+		}
+	}
+}
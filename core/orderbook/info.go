@@ -0,0 +1,124 @@
+package orderbook
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// SettlementStatus is the status of an order in the RenExSettlement contract.
+type SettlementStatus uint8
+
+// Values for SettlementStatus. An order is slashed when it was matched for an
+// atomic swap that its trader did not complete.
+const (
+	SettlementStatusNone SettlementStatus = iota
+	SettlementStatusSubmitted
+	SettlementStatusSettled
+	SettlementStatusSlashed
+)
+
+// infoConcurrency is the number of orders fetched at a time by OrderInfos.
+const infoConcurrency = 8
+
+// OrderDetails are the details of an order submitted to the RenExSettlement
+// contract.
+type OrderDetails struct {
+	SettlementID  uint64
+	Tokens        order.Tokens
+	Price         *big.Int
+	Volume        *big.Int
+	MinimumVolume *big.Int
+}
+
+// MatchDetails are the details of a settled match, from the point of view of
+// the order.
+type MatchDetails struct {
+	Settled         bool
+	OrderIsBuy      bool
+	MatchedID       order.ID
+	PriorityVolume  *big.Int
+	SecondaryVolume *big.Int
+	PriorityFee     *big.Int
+	SecondaryFee    *big.Int
+	PriorityToken   order.Token
+	SecondaryToken  order.Token
+}
+
+// OrderInfo is everything that the Orderbook and RenExSettlement contracts
+// know about an order. Details and MatchTimestamp are only set once the order
+// has been submitted for settlement, and MatchDetails once it is settled or
+// slashed.
+type OrderInfo struct {
+	ID order.ID
+
+	Status      order.Status
+	Trader      string
+	Confirmer   string
+	Match       order.ID
+	BlockNumber uint64
+	Priority    uint64
+	Depth       uint64
+
+	SettlementStatus SettlementStatus
+	Submitter        string
+	Details          *OrderDetails
+	MatchTimestamp   uint64
+	MatchDetails     *MatchDetails
+}
+
+// OrderInfos returns the OrderInfo of several orders in the same order as
+// their IDs. The orders are fetched concurrently, and the first error stops
+// the remaining orders from being fetched.
+func (service *service) OrderInfos(ctx context.Context, ids ...order.ID) ([]OrderInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	infos := make([]OrderInfo, len(ids))
+	indices := make(chan int)
+
+	mu := new(sync.Mutex)
+	var firstErr error
+
+	wg := new(sync.WaitGroup)
+	for w := 0; w < infoConcurrency && w < len(ids); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				info, err := service.OrderInfo(ctx, ids[i])
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+					continue
+				}
+				infos[i] = info
+			}
+		}()
+	}
+
+send:
+	for i := range ids {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
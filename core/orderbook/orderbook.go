@@ -1,6 +1,8 @@
 package orderbook
 
 import (
+	"context"
+
 	"github.com/republicprotocol/republic-go/order"
 )
 
@@ -9,19 +11,54 @@ type service struct {
 }
 
 type Adapter interface {
-	Status(order.ID) (order.Status, error)
-	Settled(order.ID) (bool, error)
-	RequestOpenOrder(order order.Order) error
-	RequestCancelOrder(orderID order.ID) error
-	ListOrders() ([]order.ID, []order.Status, []string, error)
+	Status(ctx context.Context, id order.ID) (order.Status, error)
+	Settled(ctx context.Context, id order.ID) (bool, error)
+	Match(ctx context.Context, id order.ID) (order.ID, error)
+	RequestOpenOrder(ctx context.Context, order order.Order) error
+	RequestCancelOrder(ctx context.Context, orderID order.ID) error
+	ListOrders(ctx context.Context) ([]order.ID, []order.Status, []string, error)
+	OrdersCount(ctx context.Context) (int, error)
+	Orders(ctx context.Context, offset, limit int) ([]order.ID, []order.Status, []string, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	TraderAddress() string
+	OrderInfo(ctx context.Context, id order.ID) (OrderInfo, error)
+}
+
+// indexedAdapter is implemented by adapters that keep an index of the
+// orderbook, so that orders can be listed without scanning the orderbook.
+type indexedAdapter interface {
+	ListOrdersByTrader(ctx context.Context, address string) ([]order.ID, error)
+	ListOrdersByStatus(ctx context.Context, status order.Status) ([]order.ID, error)
+}
+
+// openedOrders is implemented by adapters that keep the orders opened by the
+// trader, so that watchers know when they expire and how they are settled.
+// It returns false if the order was not opened by the trader.
+type openedOrders interface {
+	OpenedOrder(ctx context.Context, id order.ID) (order.Order, bool, error)
 }
+
+// Orderbook opens, cancels and lists orders. Every method has a Context
+// variant that stops waiting for the network when the context is done.
 type Orderbook interface {
 	Status(order.ID) (order.Status, error)
+	StatusContext(ctx context.Context, id order.ID) (order.Status, error)
 	Settled(order.ID) (bool, error)
+	SettledContext(ctx context.Context, id order.ID) (bool, error)
 	OpenOrder(order order.Order) error
+	OpenOrderContext(ctx context.Context, order order.Order) error
 	CancelOrder(orderID order.ID) error
+	CancelOrderContext(ctx context.Context, orderID order.ID) error
 	ListOrdersByTrader(address string) ([]order.ID, error)
+	ListOrdersByTraderContext(ctx context.Context, address string) ([]order.ID, error)
 	ListOrdersByStatus(status order.Status) ([]order.ID, error)
+	ListOrdersByStatusContext(ctx context.Context, status order.Status) ([]order.ID, error)
+	ListOrdersPage(ctx context.Context, cursor Cursor, limit int) (Page, error)
+	Iterate(cursor Cursor, pageSize int) *OrderIterator
+	OrderInfo(ctx context.Context, id order.ID) (OrderInfo, error)
+	OrderInfos(ctx context.Context, ids ...order.ID) ([]OrderInfo, error)
+	Watch(ctx context.Context, ids ...order.ID) <-chan OrderEvent
+	WatchAll(ctx context.Context) <-chan OrderEvent
 }
 
 func NewService(adapter Adapter) Orderbook {
@@ -30,16 +67,51 @@ func NewService(adapter Adapter) Orderbook {
 	}
 }
 
+func (service *service) Status(id order.ID) (order.Status, error) {
+	return service.StatusContext(context.Background(), id)
+}
+
+func (service *service) StatusContext(ctx context.Context, id order.ID) (order.Status, error) {
+	return service.Adapter.Status(ctx, id)
+}
+
+func (service *service) Settled(id order.ID) (bool, error) {
+	return service.SettledContext(context.Background(), id)
+}
+
+func (service *service) SettledContext(ctx context.Context, id order.ID) (bool, error) {
+	return service.Adapter.Settled(ctx, id)
+}
+
 func (service *service) OpenOrder(order order.Order) error {
-	return service.RequestOpenOrder(order)
+	return service.OpenOrderContext(context.Background(), order)
+}
+
+// OpenOrderContext validates an order, see ValidateOrder, and opens it.
+func (service *service) OpenOrderContext(ctx context.Context, order order.Order) error {
+	if err := ValidateOrder(order); err != nil {
+		return err
+	}
+	return service.RequestOpenOrder(ctx, order)
 }
 
 func (service *service) CancelOrder(orderID order.ID) error {
-	return service.RequestCancelOrder(orderID)
+	return service.CancelOrderContext(context.Background(), orderID)
+}
+
+func (service *service) CancelOrderContext(ctx context.Context, orderID order.ID) error {
+	return service.RequestCancelOrder(ctx, orderID)
 }
 
 func (service *service) ListOrdersByTrader(traderAddress string) ([]order.ID, error) {
-	orderIds, _, addresses, err := service.ListOrders()
+	return service.ListOrdersByTraderContext(context.Background(), traderAddress)
+}
+
+func (service *service) ListOrdersByTraderContext(ctx context.Context, traderAddress string) ([]order.ID, error) {
+	if adapter, ok := service.Adapter.(indexedAdapter); ok {
+		return adapter.ListOrdersByTrader(ctx, traderAddress)
+	}
+	orderIds, _, addresses, err := service.ListOrders(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +125,14 @@ func (service *service) ListOrdersByTrader(traderAddress string) ([]order.ID, er
 }
 
 func (service *service) ListOrdersByStatus(status order.Status) ([]order.ID, error) {
-	orderIds, statuses, _, err := service.ListOrders()
+	return service.ListOrdersByStatusContext(context.Background(), status)
+}
+
+func (service *service) ListOrdersByStatusContext(ctx context.Context, status order.Status) ([]order.ID, error) {
+	if adapter, ok := service.Adapter.(indexedAdapter); ok {
+		return adapter.ListOrdersByStatus(ctx, status)
+	}
+	orderIds, statuses, _, err := service.ListOrders(ctx)
 	if err != nil {
 		return nil, err
 	}
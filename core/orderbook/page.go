@@ -0,0 +1,130 @@
+package orderbook
+
+import (
+	"context"
+
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// DefaultPageSize is the number of orders in a page when no limit is given.
+const DefaultPageSize = 500
+
+// Cursor is the position of an order in the orderbook. Orders are only ever
+// appended to the orderbook, so a cursor keeps pointing at the same order
+// while new orders are opened. The zero Cursor is the start of the orderbook.
+type Cursor uint64
+
+// Page is a page of the orderbook returned by ListOrdersPage. Next is the
+// cursor of the page that follows it, and is equal to the cursor of the page
+// when there are no more orders.
+type Page struct {
+	IDs      []order.ID
+	Statuses []order.Status
+	Traders  []string
+	Next     Cursor
+}
+
+// Len returns the number of orders in the page.
+func (page Page) Len() int {
+	return len(page.IDs)
+}
+
+// ListOrdersPage returns at most limit orders of the orderbook starting at a
+// cursor. Orders opened after the page is returned are listed in later pages.
+func (service *service) ListOrdersPage(ctx context.Context, cursor Cursor, limit int) (Page, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	count, err := service.OrdersCount(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	if uint64(cursor) >= uint64(count) {
+		return Page{Next: cursor}, nil
+	}
+	if remaining := count - int(cursor); remaining < limit {
+		limit = remaining
+	}
+
+	ids, statuses, traders, err := service.Orders(ctx, int(cursor), limit)
+	if err != nil {
+		return Page{}, err
+	}
+	return Page{
+		IDs:      ids,
+		Statuses: statuses,
+		Traders:  traders,
+		Next:     cursor + Cursor(len(ids)),
+	}, nil
+}
+
+// Iterate returns an OrderIterator over the orderbook starting at a cursor,
+// that fetches pageSize orders at a time.
+func (service *service) Iterate(cursor Cursor, pageSize int) *OrderIterator {
+	return &OrderIterator{
+		orderbook: service,
+		cursor:    cursor,
+		pageSize:  pageSize,
+	}
+}
+
+// OrderIterator streams through the orderbook one page at a time, so that the
+// whole orderbook is never held in memory. Iteration stops at the end of the
+// orderbook, including orders opened while iterating.
+//
+//	it := orderbook.Iterate(0, 0)
+//	for it.Next(ctx) {
+//		id, status, trader := it.Order()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type OrderIterator struct {
+	orderbook Orderbook
+	cursor    Cursor
+	pageSize  int
+
+	page Page
+	i    int
+	err  error
+}
+
+// Next advances to the next order, fetching the next page when needed. It
+// returns false at the end of the orderbook, or when a page cannot be fetched.
+func (it *OrderIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.i+1 < it.page.Len() {
+		it.i++
+		return true
+	}
+
+	page, err := it.orderbook.ListOrdersPage(ctx, it.cursor, it.pageSize)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if page.Len() == 0 {
+		return false
+	}
+	it.page, it.i, it.cursor = page, 0, page.Next
+	return true
+}
+
+// Order returns the ID, status and trader of the current order.
+func (it *OrderIterator) Order() (order.ID, order.Status, string) {
+	return it.page.IDs[it.i], it.page.Statuses[it.i], it.page.Traders[it.i]
+}
+
+// Cursor returns the cursor of the current order, which can be used to resume
+// iterating from it later.
+func (it *OrderIterator) Cursor() Cursor {
+	return it.page.Next - Cursor(it.page.Len()-it.i)
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *OrderIterator) Err() error {
+	return it.err
+}
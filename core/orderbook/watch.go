@@ -0,0 +1,214 @@
+package orderbook
+
+import (
+	"context"
+	"time"
+
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// OrderEvent is sent by Watch and WatchAll when the status of an order
+// changes, when a confirmed order is settled, or when an open order expires.
+// The first event of an order has the zero status as its OldStatus.
+type OrderEvent struct {
+	ID        order.ID
+	OldStatus order.Status
+	NewStatus order.Status
+	Match     order.ID
+	Settled   bool
+	Expired   bool
+	Block     uint64
+}
+
+// watchInterval is the time between checks of the chain head by watchers. The
+// orders are only polled when a new block has been mined.
+var watchInterval = 5 * time.Second
+
+type watched struct {
+	status  order.Status
+	match   order.ID
+	settled bool
+	expired bool
+
+	// The expiry and settlement of the order are only known for orders that
+	// were opened by the trader, see openedOrders
+	loaded bool
+	expiry time.Time
+	atomic bool
+}
+
+// done returns true once the order cannot change again. Open orders stay open
+// on chain after they expire, and atomic swaps are settled outside of the
+// RenExSettlement contract, so expired orders and atomic matches are done.
+func (w watched) done() bool {
+	return w.status == order.Canceled || w.settled || w.expired || (w.atomic && w.status == order.Confirmed)
+}
+
+// Watch returns a channel of events for a set of orders. The channel is
+// closed once every order has been cancelled, settled or has expired, or when
+// the context is done. Errors while polling are retried at the next block.
+func (service *service) Watch(ctx context.Context, ids ...order.ID) <-chan OrderEvent {
+	orders := make(map[order.ID]*watched, len(ids))
+	for _, id := range ids {
+		orders[id] = &watched{}
+	}
+	return service.watch(ctx, orders, false)
+}
+
+// WatchAll returns a channel of events for every order of the trader,
+// including orders opened after it is called, which are found by paging
+// through the orderbook from its length at the time of the call. The channel
+// is closed when the context is done.
+func (service *service) WatchAll(ctx context.Context) <-chan OrderEvent {
+	return service.watch(ctx, map[order.ID]*watched{}, true)
+}
+
+func (service *service) watch(ctx context.Context, orders map[order.ID]*watched, all bool) <-chan OrderEvent {
+	events := make(chan OrderEvent)
+	go func() {
+		defer close(events)
+
+		var lastBlock uint64
+		var cursor Cursor
+		started := !all
+		for {
+			block, err := service.BlockNumber(ctx)
+			if err == nil && block != lastBlock {
+				if !started {
+					cursor, started = service.existing(ctx, orders)
+				}
+				if started && all {
+					cursor = service.discover(ctx, orders, cursor)
+				}
+				if started && service.poll(ctx, orders, block, events) {
+					lastBlock = block
+				}
+			}
+			if !all && len(orders) == 0 {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchInterval):
+			}
+		}
+	}()
+	return events
+}
+
+// existing adds the orders that the trader has opened so far, and returns the
+// cursor from which new orders are discovered. It returns false if the orders
+// could not be listed.
+func (service *service) existing(ctx context.Context, orders map[order.ID]*watched) (Cursor, bool) {
+	count, err := service.OrdersCount(ctx)
+	if err != nil {
+		return 0, false
+	}
+	ids, err := service.ListOrdersByTraderContext(ctx, service.TraderAddress())
+	if err != nil {
+		return 0, false
+	}
+	for _, id := range ids {
+		if _, ok := orders[id]; !ok {
+			orders[id] = &watched{}
+		}
+	}
+	return Cursor(count), true
+}
+
+// discover adds the orders of the trader that have been opened since the
+// cursor, one page at a time, and returns the cursor that follows the last
+// page that was fetched.
+func (service *service) discover(ctx context.Context, orders map[order.ID]*watched, cursor Cursor) Cursor {
+	trader := service.TraderAddress()
+	for {
+		page, err := service.ListOrdersPage(ctx, cursor, DefaultPageSize)
+		if err != nil || page.Len() == 0 {
+			return cursor
+		}
+		for i, id := range page.IDs {
+			if page.Traders[i] == trader {
+				if _, ok := orders[id]; !ok {
+					orders[id] = &watched{}
+				}
+			}
+		}
+		cursor = page.Next
+	}
+}
+
+// load sets the expiry and settlement of an order from the orders opened by
+// the trader, when the adapter keeps them.
+func (service *service) load(ctx context.Context, id order.ID, w *watched) error {
+	if adapter, ok := service.Adapter.(openedOrders); ok {
+		ord, found, err := adapter.OpenedOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if found {
+			w.expiry = ord.Expiry
+			w.atomic = ord.Settlement == order.SettlementRenExAtomic
+		}
+	}
+	w.loaded = true
+	return nil
+}
+
+// poll checks every watched order and sends an event for each change. Orders
+// that can no longer change are removed. It returns false if any order could
+// not be checked.
+func (service *service) poll(ctx context.Context, orders map[order.ID]*watched, block uint64, events chan<- OrderEvent) bool {
+	ok := true
+	for id, w := range orders {
+		if !w.loaded {
+			if err := service.load(ctx, id, w); err != nil {
+				ok = false
+				continue
+			}
+		}
+		status, err := service.StatusContext(ctx, id)
+		if err != nil {
+			ok = false
+			continue
+		}
+		next := *w
+		next.status = status
+		next.expired = status == order.Open && !w.expiry.IsZero() && !time.Now().Before(w.expiry)
+		if status == order.Confirmed {
+			if next.match == (order.ID{}) {
+				if next.match, err = service.Match(ctx, id); err != nil {
+					ok = false
+					continue
+				}
+			}
+			if next.settled, err = service.SettledContext(ctx, id); err != nil {
+				ok = false
+				continue
+			}
+		}
+		if next == *w {
+			continue
+		}
+
+		select {
+		case events <- OrderEvent{
+			ID:        id,
+			OldStatus: w.status,
+			NewStatus: next.status,
+			Match:     next.match,
+			Settled:   next.settled,
+			Expired:   next.expired,
+			Block:     block,
+		}:
+		case <-ctx.Done():
+			return false
+		}
+		*w = next
+		if w.done() {
+			delete(orders, id)
+		}
+	}
+	return ok
+}
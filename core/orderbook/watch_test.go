@@ -0,0 +1,257 @@
+package orderbook
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/republicprotocol/republic-go/order"
+)
+
+const watchTrader = "0x0000000000000000000000000000000000000001"
+
+// fakeAdapter is an orderbook whose orders are changed by the test. Every
+// call to BlockNumber mines a block, so that watchers poll at every tick.
+type fakeAdapter struct {
+	mu       *sync.Mutex
+	block    uint64
+	ids      []order.ID
+	traders  []string
+	statuses map[order.ID]order.Status
+	matches  map[order.ID]order.ID
+	settled  map[order.ID]bool
+}
+
+func newFakeAdapter() *fakeAdapter {
+	return &fakeAdapter{
+		mu:       new(sync.Mutex),
+		ids:      []order.ID{},
+		traders:  []string{},
+		statuses: map[order.ID]order.Status{},
+		matches:  map[order.ID]order.ID{},
+		settled:  map[order.ID]bool{},
+	}
+}
+
+func (adapter *fakeAdapter) open(id order.ID, trader string) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	adapter.ids = append(adapter.ids, id)
+	adapter.traders = append(adapter.traders, trader)
+	adapter.statuses[id] = order.Open
+}
+
+func (adapter *fakeAdapter) set(id order.ID, status order.Status, match order.ID, settled bool) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	adapter.statuses[id] = status
+	adapter.matches[id] = match
+	adapter.settled[id] = settled
+}
+
+func (adapter *fakeAdapter) Status(ctx context.Context, id order.ID) (order.Status, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	return adapter.statuses[id], nil
+}
+
+func (adapter *fakeAdapter) Settled(ctx context.Context, id order.ID) (bool, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	return adapter.settled[id], nil
+}
+
+func (adapter *fakeAdapter) Match(ctx context.Context, id order.ID) (order.ID, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	return adapter.matches[id], nil
+}
+
+func (adapter *fakeAdapter) RequestOpenOrder(ctx context.Context, ord order.Order) error {
+	adapter.open(ord.ID, watchTrader)
+	return nil
+}
+
+func (adapter *fakeAdapter) RequestCancelOrder(ctx context.Context, id order.ID) error {
+	adapter.set(id, order.Canceled, order.ID{}, false)
+	return nil
+}
+
+func (adapter *fakeAdapter) ListOrders(ctx context.Context) ([]order.ID, []order.Status, []string, error) {
+	count, _ := adapter.OrdersCount(ctx)
+	return adapter.Orders(ctx, 0, count)
+}
+
+func (adapter *fakeAdapter) OrdersCount(ctx context.Context) (int, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	return len(adapter.ids), nil
+}
+
+func (adapter *fakeAdapter) Orders(ctx context.Context, offset, limit int) ([]order.ID, []order.Status, []string, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	end := offset + limit
+	if end > len(adapter.ids) {
+		end = len(adapter.ids)
+	}
+	ids := append([]order.ID{}, adapter.ids[offset:end]...)
+	traders := append([]string{}, adapter.traders[offset:end]...)
+	statuses := make([]order.Status, len(ids))
+	for i, id := range ids {
+		statuses[i] = adapter.statuses[id]
+	}
+	return ids, statuses, traders, nil
+}
+
+func (adapter *fakeAdapter) BlockNumber(ctx context.Context) (uint64, error) {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	adapter.block++
+	return adapter.block, nil
+}
+
+func (adapter *fakeAdapter) TraderAddress() string {
+	return watchTrader
+}
+
+func (adapter *fakeAdapter) OrderInfo(ctx context.Context, id order.ID) (OrderInfo, error) {
+	status, _ := adapter.Status(ctx, id)
+	return OrderInfo{ID: id, Status: status}, nil
+}
+
+// fakeOpenedAdapter also keeps the orders opened by the trader.
+type fakeOpenedAdapter struct {
+	*fakeAdapter
+	opened map[order.ID]order.Order
+}
+
+func (adapter fakeOpenedAdapter) OpenedOrder(ctx context.Context, id order.ID) (order.Order, bool, error) {
+	ord, ok := adapter.opened[id]
+	return ord, ok, nil
+}
+
+// fastWatch makes watchers poll every millisecond, and returns a function that
+// restores the interval.
+func fastWatch() func() {
+	interval := watchInterval
+	watchInterval = time.Millisecond
+	return func() {
+		watchInterval = interval
+	}
+}
+
+func nextEvent(t *testing.T, events <-chan OrderEvent) OrderEvent {
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("events closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return OrderEvent{}
+}
+
+func expectClosed(t *testing.T, events <-chan OrderEvent) {
+	select {
+	case event, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for events to be closed")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	defer fastWatch()()
+	adapter := newFakeAdapter()
+	a, b, match := order.ID{1}, order.ID{2}, order.ID{3}
+	adapter.open(a, watchTrader)
+	adapter.open(b, watchTrader)
+	adapter.set(b, order.Canceled, order.ID{}, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := NewService(adapter).Watch(ctx, a, b)
+
+	first := map[order.ID]OrderEvent{}
+	for i := 0; i < 2; i++ {
+		event := nextEvent(t, events)
+		first[event.ID] = event
+	}
+	if event := first[a]; event.OldStatus != order.Status(0) || event.NewStatus != order.Open {
+		t.Errorf("got first event %+v for a, want the zero status to Open", event)
+	}
+	if event := first[b]; event.OldStatus != order.Status(0) || event.NewStatus != order.Canceled {
+		t.Errorf("got first event %+v for b, want the zero status to Canceled", event)
+	}
+
+	adapter.set(a, order.Confirmed, match, true)
+	event := nextEvent(t, events)
+	if event.ID != a || event.OldStatus != order.Open || event.NewStatus != order.Confirmed || event.Match != match || !event.Settled {
+		t.Errorf("got event %+v, want a settled with its match", event)
+	}
+	expectClosed(t, events)
+}
+
+func TestWatchExpiredAndAtomic(t *testing.T) {
+	defer fastWatch()()
+	adapter := newFakeAdapter()
+	expired, atomic := order.ID{1}, order.ID{2}
+	adapter.open(expired, watchTrader)
+	adapter.open(atomic, watchTrader)
+	adapter.set(atomic, order.Confirmed, order.ID{3}, false)
+	opened := fakeOpenedAdapter{
+		fakeAdapter: adapter,
+		opened: map[order.ID]order.Order{
+			expired: {ID: expired, Expiry: time.Now().Add(-time.Minute), Settlement: order.SettlementRenEx},
+			atomic:  {ID: atomic, Expiry: time.Now().Add(time.Hour), Settlement: order.SettlementRenExAtomic},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := NewService(opened).Watch(ctx, expired, atomic)
+
+	for i := 0; i < 2; i++ {
+		event := nextEvent(t, events)
+		switch event.ID {
+		case expired:
+			if event.NewStatus != order.Open || !event.Expired {
+				t.Errorf("got event %+v, want an expired open order", event)
+			}
+		case atomic:
+			if event.NewStatus != order.Confirmed || event.Settled {
+				t.Errorf("got event %+v, want an unsettled confirmed order", event)
+			}
+		}
+	}
+	expectClosed(t, events)
+}
+
+func TestWatchAll(t *testing.T) {
+	defer fastWatch()()
+	adapter := newFakeAdapter()
+	mine, other, later := order.ID{1}, order.ID{2}, order.ID{3}
+	adapter.open(mine, watchTrader)
+	adapter.open(other, "0x0000000000000000000000000000000000000002")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := NewService(adapter).WatchAll(ctx)
+
+	if event := nextEvent(t, events); event.ID != mine || event.NewStatus != order.Open {
+		t.Errorf("got event %+v, want the open order of the trader", event)
+	}
+	adapter.open(later, watchTrader)
+	if event := nextEvent(t, events); event.ID != later || event.NewStatus != order.Open {
+		t.Errorf("got event %+v, want the order opened after watching", event)
+	}
+
+	cancel()
+	for range events {
+	}
+}
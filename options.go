@@ -0,0 +1,148 @@
+package renex
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/ingress"
+	obAdapter "github.com/republicprotocol/renex-sdk-go/adapter/orderbook"
+	"github.com/republicprotocol/renex-sdk-go/adapter/store"
+	"github.com/republicprotocol/renex-sdk-go/adapter/trader"
+)
+
+// An Option configures a RenEx created by NewRenExWithOptions.
+type Option func(*options)
+
+type options struct {
+	network       *client.Network
+	ingressURL    string
+	httpClient    *http.Client
+	ingressConfig ingress.Config
+	storeAdapter  store.StoreAdapter
+	storePath     string
+	trader        trader.Trader
+	signer        trader.Signer
+	keystorePath  string
+	passphrase    string
+	client        client.Client
+	indexInterval time.Duration
+	index         *obAdapter.Index
+}
+
+// WithNetwork sets the network definition. When no client is given, the RPC
+// endpoint and contracts of the network are used to create one.
+func WithNetwork(network client.Network) Option {
+	return func(opts *options) {
+		opts.network = &network
+	}
+}
+
+// WithIngressURL sets the ingress that approves orders and withdrawals. It
+// defaults to the ingress of the network.
+func WithIngressURL(url string) Option {
+	return func(opts *options) {
+		opts.ingressURL = url
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to talk to the ingress. It defaults
+// to http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(opts *options) {
+		opts.httpClient = httpClient
+	}
+}
+
+// WithIngressConfig sets the timeouts and retries of requests to the
+// ingress. It defaults to ingress.DefaultConfig.
+func WithIngressConfig(config ingress.Config) Option {
+	return func(opts *options) {
+		opts.ingressConfig = config
+	}
+}
+
+// WithStore sets the store in which open orders are kept. It defaults to a
+// LevelDB store at a path derived from the network and trader.
+func WithStore(storeAdapter store.StoreAdapter) Option {
+	return func(opts *options) {
+		opts.storeAdapter = storeAdapter
+	}
+}
+
+// WithStorePath opens the default LevelDB store at a path instead of the path
+// derived from the network and trader.
+func WithStorePath(path string) Option {
+	return func(opts *options) {
+		opts.storePath = path
+	}
+}
+
+// WithTrader sets the trader that signs orders and transactions. The trader is
+// used as it is, so it only has the nonce manager, journal and chain ID that
+// it was created with, see WithSigner.
+func WithTrader(trader trader.Trader) Option {
+	return func(opts *options) {
+		opts.trader = trader
+	}
+}
+
+// WithSigner creates the trader from a Signer. Like a trader loaded with
+// WithKeystore, it keeps its nonces and the journal of its transactions in the
+// store and signs with the chain ID of the client.
+func WithSigner(signer trader.Signer) Option {
+	return func(opts *options) {
+		opts.signer = signer
+	}
+}
+
+// WithKeystore loads the trader from a keystore file.
+func WithKeystore(keystorePath, passphrase string) Option {
+	return func(opts *options) {
+		opts.keystorePath = keystorePath
+		opts.passphrase = passphrase
+	}
+}
+
+// WithClient sets the Ethereum client.
+func WithClient(client client.Client) Option {
+	return func(opts *options) {
+		opts.client = client
+	}
+}
+
+// WithIndexInterval sets the time between syncs of the local index of the
+// orderbook that is used to list orders by trader and status. It defaults to
+// obAdapter.DefaultSyncInterval.
+func WithIndexInterval(interval time.Duration) Option {
+	return func(opts *options) {
+		opts.indexInterval = interval
+	}
+}
+
+// WithIndex sets the local index of the orderbook, so that it can be shared by
+// several RenEx on the same network. An index that is given is not closed when
+// the RenEx is closed.
+func WithIndex(index *obAdapter.Index) Option {
+	return func(opts *options) {
+		opts.index = index
+	}
+}
+
+// networkDefinition returns the network set with WithNetwork, or the network
+// of the client set with WithClient.
+func (o options) networkDefinition() (client.Network, error) {
+	switch {
+	case o.network != nil:
+		return *o.network, nil
+	case o.client != nil:
+		network, err := client.GetNetwork(o.client.Network())
+		if err != nil {
+			network = client.Network{Name: o.client.Network()}
+		}
+		return network, nil
+	default:
+		return client.Network{}, errors.New("cannot create renex: no network or client")
+	}
+}
@@ -0,0 +1,371 @@
+package renex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/leveldb"
+	obAdapter "github.com/republicprotocol/renex-sdk-go/adapter/orderbook"
+	"github.com/republicprotocol/renex-sdk-go/adapter/store"
+	"github.com/republicprotocol/renex-sdk-go/adapter/trader"
+	"github.com/republicprotocol/renex-sdk-go/core/funds"
+	"github.com/republicprotocol/republic-go/order"
+)
+
+// ErrNoAccounts is returned when a Pool has no accounts.
+var ErrNoAccounts = errors.New("no accounts in pool")
+
+// ErrUnknownAccount is returned when an account is not in a Pool.
+var ErrUnknownAccount = errors.New("unknown account")
+
+// ErrAccountExists is returned when an account is added to a Pool twice.
+var ErrAccountExists = errors.New("account already in pool")
+
+// A Pool manages the accounts of several traders that share a network. Each
+// account is a RenEx with its own trader and store. Orders are opened by an
+// account with enough usable balance, and are cancelled by the account that
+// opened them.
+type Pool struct {
+	opts []Option
+
+	sharedMu *sync.Mutex
+	client   client.Client
+	index    *obAdapter.Index
+	closers  []io.Closer
+
+	mu       *sync.RWMutex
+	accounts []RenEx
+	owners   map[order.ID]common.Address
+}
+
+// NewPool returns an empty Pool. The options are used to create the RenEx of
+// every account added to the pool. The accounts share one connection to the
+// network, which is the client given with WithClient or a client created by
+// the pool, and one local index of the orderbook, which is the index given
+// with WithIndex or an index that the pool keeps in its own store, see
+// leveldb.IndexPath. Components that are created by the pool are closed when
+// the pool is closed.
+func NewPool(opts ...Option) *Pool {
+	return &Pool{
+		opts:     opts,
+		sharedMu: new(sync.Mutex),
+		mu:       new(sync.RWMutex),
+		accounts: []RenEx{},
+		owners:   map[order.ID]common.Address{},
+	}
+}
+
+// Add creates the account of a trader and adds it to the pool. The options
+// are applied after the options of the pool, and the trader is used as it is,
+// see WithTrader. Accounts must not share a store, since the store determines
+// the balance locked by open orders.
+func (pool *Pool) Add(t trader.Trader, opts ...Option) (RenEx, error) {
+	pool.mu.RLock()
+	_, err := pool.account(t.Address())
+	pool.mu.RUnlock()
+	if err == nil {
+		return RenEx{}, ErrAccountExists
+	}
+
+	shared, err := pool.shared()
+	if err != nil {
+		return RenEx{}, err
+	}
+
+	// The account is created without holding the lock, since it connects to
+	// the network. The trader is set last, so that the account always
+	// belongs to the trader that it is added for.
+	options := append(append([]Option{}, pool.opts...), shared...)
+	options = append(append(options, opts...), WithTrader(t))
+	account, err := NewRenExWithOptions(options...)
+	if err != nil {
+		return RenEx{}, err
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if _, err := pool.account(t.Address()); err == nil {
+		account.Close()
+		return RenEx{}, ErrAccountExists
+	}
+	pool.accounts = append(pool.accounts, account)
+	return account, nil
+}
+
+// shared returns the options that give an account the client and index of the
+// pool. They are created when the first account is added.
+func (pool *Pool) shared() ([]Option, error) {
+	pool.sharedMu.Lock()
+	defer pool.sharedMu.Unlock()
+	if pool.index == nil {
+		if err := pool.connect(); err != nil {
+			return nil, err
+		}
+	}
+	return []Option{WithClient(pool.client), WithIndex(pool.index)}, nil
+}
+
+func (pool *Pool) connect() (err error) {
+	// Close the components created so far if the pool cannot connect
+	closers := []io.Closer{}
+	defer func() {
+		if err != nil {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i].Close()
+			}
+		}
+	}()
+
+	o := options{}
+	for _, opt := range pool.opts {
+		opt(&o)
+	}
+	network, err := o.networkDefinition()
+	if err != nil {
+		return err
+	}
+
+	newClient := o.client
+	if newClient == nil {
+		if newClient, err = client.NewClientFromNetwork(network); err != nil {
+			return err
+		}
+		closers = append(closers, newClient)
+	}
+
+	newIndex := o.index
+	if newIndex == nil {
+		storeAdapter, err := leveldb.NewLDBStore(leveldb.IndexPath(leveldb.DefaultRoot(), network.Name))
+		if err != nil {
+			return err
+		}
+		closers = append(closers, storeAdapter)
+		if newIndex, err = obAdapter.NewIndex(newClient, storeAdapter, o.indexInterval); err != nil {
+			return err
+		}
+		closers = append(closers, newIndex)
+	}
+
+	pool.client = newClient
+	pool.index = newIndex
+	pool.closers = closers
+	return nil
+}
+
+// Close closes the accounts of the pool, and then the client and index that
+// the pool created for them. The pool must not be used after it is closed.
+func (pool *Pool) Close() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var err error
+	for _, account := range pool.accounts {
+		if closeErr := account.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	pool.sharedMu.Lock()
+	defer pool.sharedMu.Unlock()
+	for i := len(pool.closers) - 1; i >= 0; i-- {
+		if closeErr := pool.closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Accounts returns the addresses of the accounts in the order that they were
+// added.
+func (pool *Pool) Accounts() []common.Address {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	addresses := make([]common.Address, len(pool.accounts))
+	for i, account := range pool.accounts {
+		addresses[i] = account.trader.Address()
+	}
+	return addresses
+}
+
+// Account returns the account of a trader.
+func (pool *Pool) Account(address common.Address) (RenEx, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	return pool.account(address)
+}
+
+func (pool *Pool) account(address common.Address) (RenEx, error) {
+	for _, account := range pool.accounts {
+		if account.trader.Address() == address {
+			return account, nil
+		}
+	}
+	return RenEx{}, ErrUnknownAccount
+}
+
+func (pool *Pool) snapshot() []RenEx {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	return append([]RenEx{}, pool.accounts...)
+}
+
+// Balance returns the sum of the balances of a token held by the accounts.
+func (pool *Pool) Balance(ctx context.Context, token order.Token) (*big.Int, error) {
+	return pool.sum(func(account RenEx) (*big.Int, error) {
+		return account.BalanceContext(ctx, token)
+	})
+}
+
+// RenExBalance returns the sum of the RenEx balances of a token held by the
+// accounts.
+func (pool *Pool) RenExBalance(ctx context.Context, token order.Token) (*big.Int, error) {
+	return pool.sum(func(account RenEx) (*big.Int, error) {
+		return account.RenExBalanceContext(ctx, token)
+	})
+}
+
+// UsableRenExBalance returns the sum of the RenEx balances of a token that
+// are not locked by open orders.
+func (pool *Pool) UsableRenExBalance(ctx context.Context, token order.Token) (*big.Int, error) {
+	return pool.sum(func(account RenEx) (*big.Int, error) {
+		return account.UsableRenExBalanceContext(ctx, token)
+	})
+}
+
+func (pool *Pool) sum(balance func(RenEx) (*big.Int, error)) (*big.Int, error) {
+	total := big.NewInt(0)
+	for _, account := range pool.snapshot() {
+		value, err := balance(account)
+		if err != nil {
+			return nil, err
+		}
+		total.Add(total, value)
+	}
+	return total, nil
+}
+
+// OpenOrder opens an order with the first account that has enough usable
+// balance to cover its volume, and returns the address of that account. If no
+// account has enough balance, an InsufficientBalanceError is returned with the
+// largest usable balance of the accounts.
+func (pool *Pool) OpenOrder(ctx context.Context, ord order.Order) (common.Address, error) {
+	accounts := pool.snapshot()
+	if len(accounts) == 0 {
+		return common.Address{}, ErrNoAccounts
+	}
+
+	token := ord.Tokens.NonPriorityToken()
+	volume := big.NewInt(int64(ord.Volume))
+	var owner *RenEx
+	best := big.NewInt(0)
+	for i := range accounts {
+		balance, err := accounts[i].UsableRenExBalanceContext(ctx, token)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if balance.Cmp(volume) >= 0 {
+			owner = &accounts[i]
+			break
+		}
+		if balance.Cmp(best) > 0 {
+			best = balance
+		}
+	}
+	if owner == nil {
+		return common.Address{}, funds.InsufficientBalanceError{Token: token, Have: best, Want: volume}
+	}
+
+	if err := owner.OpenOrderContext(ctx, ord); err != nil {
+		return common.Address{}, err
+	}
+	// The owner is kept in the store of the account, so that orders can be
+	// cancelled after a restart
+	if err := owner.storeAdapter.Write(ownerKey(ord.ID), owner.trader.Address().Bytes()); err != nil {
+		return common.Address{}, err
+	}
+	pool.mu.Lock()
+	pool.owners[ord.ID] = owner.trader.Address()
+	pool.mu.Unlock()
+	return owner.trader.Address(), nil
+}
+
+// Owner returns the address of the account that opened an order. Orders that
+// were not opened through the pool are looked up in the Orderbook contract.
+func (pool *Pool) Owner(ctx context.Context, id order.ID) (common.Address, error) {
+	pool.mu.RLock()
+	owner, ok := pool.owners[id]
+	pool.mu.RUnlock()
+	if ok {
+		return owner, nil
+	}
+
+	accounts := pool.snapshot()
+	if len(accounts) == 0 {
+		return common.Address{}, ErrNoAccounts
+	}
+	for _, account := range accounts {
+		data, err := account.storeAdapter.Read(ownerKey(id))
+		if err == store.ErrOrdersNotFound {
+			continue
+		}
+		if err != nil {
+			return common.Address{}, err
+		}
+		owner = common.BytesToAddress(data)
+		pool.mu.Lock()
+		pool.owners[id] = owner
+		pool.mu.Unlock()
+		return owner, nil
+	}
+	orderbookContract, err := bindings.NewOrderbookCaller(accounts[0].client.OrderbookAddress(), accounts[0].client.Client())
+	if err != nil {
+		return common.Address{}, err
+	}
+	owner, err = orderbookContract.OrderTrader(&bind.CallOpts{Context: ctx}, id)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, err := pool.Account(owner); err != nil {
+		return common.Address{}, err
+	}
+
+	pool.mu.Lock()
+	pool.owners[id] = owner
+	pool.mu.Unlock()
+	return owner, nil
+}
+
+// CancelOrder cancels an order with the account that opened it.
+func (pool *Pool) CancelOrder(ctx context.Context, id order.ID) error {
+	owner, err := pool.Owner(ctx, id)
+	if err != nil {
+		return err
+	}
+	account, err := pool.Account(owner)
+	if err != nil {
+		return err
+	}
+	return account.CancelOrderContext(ctx, id)
+}
+
+// Withdraw withdraws a token from the RenEx balance of an account, see
+// funds.Funds.Withdraw.
+func (pool *Pool) Withdraw(ctx context.Context, address common.Address, token order.Token, value *big.Int, forced bool, key *funds.IdempotentKey) (*funds.IdempotentKey, error) {
+	account, err := pool.Account(address)
+	if err != nil {
+		return nil, err
+	}
+	return account.WithdrawContext(ctx, token, value, forced, key)
+}
+
+func ownerKey(id order.ID) []byte {
+	return append([]byte("OWNER"), id[:]...)
+}
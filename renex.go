@@ -1,68 +1,331 @@
 package renex
 
 import (
-	"crypto/rand"
-	"encoding/hex"
-	"fmt"
-	"os"
+	"context"
+	"errors"
+	"io"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/republicprotocol/renex-sdk-go/adapter/bindings"
 	"github.com/republicprotocol/renex-sdk-go/adapter/client"
 	fundsAdapter "github.com/republicprotocol/renex-sdk-go/adapter/funds"
+	"github.com/republicprotocol/renex-sdk-go/adapter/ingress"
+	"github.com/republicprotocol/renex-sdk-go/adapter/journal"
 	"github.com/republicprotocol/renex-sdk-go/adapter/leveldb"
+	"github.com/republicprotocol/renex-sdk-go/adapter/local"
 	obAdapter "github.com/republicprotocol/renex-sdk-go/adapter/orderbook"
 	"github.com/republicprotocol/renex-sdk-go/adapter/store"
 	"github.com/republicprotocol/renex-sdk-go/adapter/trader"
 
 	"github.com/republicprotocol/renex-sdk-go/core/funds"
 	"github.com/republicprotocol/renex-sdk-go/core/orderbook"
+	"github.com/republicprotocol/republic-go/order"
 )
 
+// migratedKey is written to the store of a trader once the orders of the
+// legacy stores have been migrated into it.
+var migratedKey = []byte("MIGRATED")
+
 type RenEx struct {
 	orderbook.Orderbook
 	funds.Funds
+
+	trader  trader.Trader
+	client  client.Client
+	ingress *ingress.Client
+	journal journal.Journal
+
+	storeAdapter store.StoreAdapter
+	index        *obAdapter.Index
+	closers      []io.Closer
 }
 
+// NewRenEx returns a RenEx connected to a network from the network registry.
+// The "local" network starts a new in-process chain, see NewLocalRenEx.
 func NewRenEx(network, keystorePath, passphrase string) (RenEx, error) {
-	ingressAddress := fmt.Sprintf("https://renex-ingress-%s.herokuapp.com", network)
-	newTrader, err := trader.NewTrader(keystorePath, passphrase)
+	if network == client.NetworkLocal {
+		renex, _, err := NewLocalRenEx(keystorePath, passphrase)
+		return renex, err
+	}
+
+	net, err := client.GetNetwork(network)
 	if err != nil {
 		return RenEx{}, err
 	}
+	return NewRenExFromNetwork(net, keystorePath, passphrase)
+}
+
+// NewRenExFromNetwork returns a RenEx connected to the RPC endpoint, ingress
+// and contracts of a network definition.
+func NewRenExFromNetwork(network client.Network, keystorePath, passphrase string) (RenEx, error) {
+	return NewRenExWithOptions(WithNetwork(network), WithKeystore(keystorePath, passphrase))
+}
+
+// NewRenExWithOptions returns a RenEx built from the given components. Any
+// component that is not given is created from the network, which must be set
+// with WithNetwork unless a client is given with WithClient. Components that
+// are created here are closed when the RenEx is closed, and components that
+// are given are left for the caller to close.
+func NewRenExWithOptions(opts ...Option) (renex RenEx, err error) {
+	// Close the components created so far if the RenEx cannot be created
+	closers := []io.Closer{}
+	defer func() {
+		if err != nil {
+			for i := len(closers) - 1; i >= 0; i-- {
+				closers[i].Close()
+			}
+		}
+	}()
+
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-	newClient, err := client.NewClient(network)
+	network, err := o.networkDefinition()
 	if err != nil {
 		return RenEx{}, err
 	}
 
-	randomDBID := make([]byte, 32)
-	if _, err := rand.Read(randomDBID); err != nil {
-		return RenEx{}, err
+	var signer trader.Signer
+	var traderAddress common.Address
+	switch {
+	case o.trader != nil:
+		traderAddress = o.trader.Address()
+	case o.signer != nil:
+		signer = o.signer
+		traderAddress = signer.Address()
+	default:
+		if o.keystorePath == "" {
+			return RenEx{}, errors.New("cannot create renex: no trader, signer or keystore")
+		}
+		var err error
+		if signer, err = trader.NewKeystoreSigner(o.keystorePath, o.passphrase); err != nil {
+			return RenEx{}, err
+		}
+		traderAddress = signer.Address()
+	}
+
+	newClient := o.client
+	if newClient == nil {
+		var err error
+		if newClient, err = client.NewClientFromNetwork(network); err != nil {
+			return RenEx{}, err
+		}
+		closers = append(closers, newClient)
+	}
+	if chainID := newClient.ChainID(); network.ChainID != 0 && chainID != nil && chainID.Uint64() != network.ChainID {
+		return RenEx{}, client.ChainMismatchError{Network: network.Name, Want: new(big.Int).SetUint64(network.ChainID), Have: chainID}
+	}
+
+	ingressURL := o.ingressURL
+	if ingressURL == "" {
+		ingressURL = network.IngressURL()
+	}
+
+	newStoreAdapter := o.storeAdapter
+	var newStore store.Store
+	if newStoreAdapter != nil {
+		newStore = store.NewStore(newStoreAdapter)
+	} else {
+		path := o.storePath
+		if path == "" {
+			path = leveldb.Path(leveldb.DefaultRoot(), network.Name, traderAddress)
+		}
+		var err error
+		if newStoreAdapter, err = leveldb.NewLDBStore(path); err != nil {
+			return RenEx{}, err
+		}
+		closers = append(closers, newStoreAdapter)
+		newStore = store.NewStore(newStoreAdapter)
+
+		// Until a migration has completed, recover the orders that were kept
+		// in the randomly named stores of earlier versions. Migrating an order
+		// twice is harmless, so an interrupted migration is simply retried.
+		if _, err := newStoreAdapter.Read(migratedKey); err != nil {
+			if err != store.ErrOrdersNotFound {
+				return RenEx{}, err
+			}
+			if _, err := MigrateLegacyStores(leveldb.DefaultRoot(), newClient, traderAddress, newStore); err != nil {
+				return RenEx{}, err
+			}
+			if err := newStoreAdapter.Write(migratedKey, []byte{1}); err != nil {
+				return RenEx{}, err
+			}
+		}
+	}
+
+	// A trader created from a signer keeps its nonces and the journal of its
+	// transactions next to its orders
+	newJournal := journal.NewJournal(newStoreAdapter)
+	newTrader := o.trader
+	if newTrader == nil {
+		newTrader = trader.NewTraderFromSigner(signer,
+			trader.WithNonceManager(trader.NewNonceManager(traderAddress, newStoreAdapter)),
+			trader.WithJournal(newJournal),
+			trader.WithChainID(newClient.ChainID()),
+		)
+	}
+
+	// Orders are listed from an index of the orderbook that is kept in the
+	// store, so that it only needs to fetch new orders after a restart
+	newIndex := o.index
+	if newIndex == nil {
+		if newIndex, err = obAdapter.NewIndex(newClient, newStoreAdapter, o.indexInterval); err != nil {
+			return RenEx{}, err
+		}
+		closers = append(closers, newIndex)
 	}
 
-	newStoreAdapter, err := leveldb.NewLDBStore(os.Getenv("HOME") + fmt.Sprintf("/.renex/db%s", hex.EncodeToString(randomDBID)))
+	renex, err = newRenEx(network, ingress.NewClient(ingressURL, o.httpClient, o.ingressConfig), newClient, newTrader, newStore, newJournal, newIndex)
 	if err != nil {
 		return RenEx{}, err
 	}
+	renex.storeAdapter = newStoreAdapter
+	renex.index = newIndex
+	renex.closers = closers
+	return renex, nil
+}
 
-	newStore := store.NewStore(newStoreAdapter)
+// NewLocalRenEx deploys the RenEx contracts to a new in-process simulated
+// chain and returns a RenEx connected to it. The trader is funded with ether
+// and every registered token, orders and withdrawals are approved by an
+// in-process ingress, and orders are kept in memory. Closing the RenEx closes
+// the chain and its ingress.
+func NewLocalRenEx(keystorePath, passphrase string) (RenEx, *local.Chain, error) {
+	signer, err := trader.NewKeystoreSigner(keystorePath, passphrase)
 	if err != nil {
-		return RenEx{}, err
+		return RenEx{}, nil, err
+	}
+
+	chain, err := local.NewChain(signer.Address())
+	if err != nil {
+		return RenEx{}, nil, err
+	}
+
+	localIngress, err := local.NewIngress(chain)
+	if err != nil {
+		return RenEx{}, nil, err
+	}
+
+	newStoreAdapter, err := leveldb.NewMemLDBStore()
+	if err != nil {
+		chain.Close()
+		return RenEx{}, nil, err
 	}
 
-	fAdapter, err := fundsAdapter.NewAdapter(ingressAddress, newClient, newTrader, newStore)
+	renex, err := NewRenExWithOptions(
+		WithNetwork(chain.Network),
+		WithClient(chain.Client),
+		WithIngressURL(localIngress.URL),
+		WithSigner(signer),
+		WithStore(newStoreAdapter),
+	)
+	if err != nil {
+		chain.Close()
+		return RenEx{}, nil, err
+	}
+	renex.closers = append([]io.Closer{chain}, renex.closers...)
+	return renex, chain, nil
+}
+
+// Close releases the resources held by the RenEx, in the reverse order that
+// they were created so that the index stops syncing before its store is
+// closed. The RenEx must not be used after it is closed.
+func (renex RenEx) Close() error {
+	var err error
+	for i := len(renex.closers) - 1; i >= 0; i-- {
+		if closeErr := renex.closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func newRenEx(network client.Network, ingressClient *ingress.Client, newClient client.Client, newTrader trader.Trader, newStore store.Store, newJournal journal.Journal, newIndex *obAdapter.Index) (RenEx, error) {
+	fAdapter, err := fundsAdapter.NewAdapter(ingressClient, newClient, newTrader, newStore, newJournal)
 	if err != nil {
 		return RenEx{}, err
 	}
 
 	fService := funds.NewService(fAdapter)
 
-	oAdapter, err := obAdapter.NewAdapter(ingressAddress, newClient, newTrader, fService, newStore, network)
+	oAdapter, err := obAdapter.NewAdapter(ingressClient, newClient, newTrader, fService, newStore, network, newIndex)
 	if err != nil {
 		return RenEx{}, err
 	}
 
 	return RenEx{
-		orderbook.NewService(oAdapter),
-		fService,
+		Orderbook: orderbook.NewService(oAdapter),
+		Funds:     fService,
+		trader:    newTrader,
+		client:    newClient,
+		ingress:   ingressClient,
+		journal:   newJournal,
 	}, nil
 }
+
+// SpeedUp replaces a pending transaction of the trader with the same
+// transaction at a higher gas price. Operations waiting for the transaction
+// complete when either transaction is mined.
+func (renex RenEx) SpeedUp(ctx context.Context, txHash common.Hash, gasPrice *big.Int) (*types.Transaction, error) {
+	return renex.trader.SpeedUp(ctx, renex.client, txHash, gasPrice)
+}
+
+// CancelTx replaces a pending transaction of the trader with a transfer of no
+// ether to itself. Operations waiting for the transaction fail with a
+// client.TxCanceledError when the cancellation is mined.
+func (renex RenEx) CancelTx(ctx context.Context, txHash common.Hash) (*types.Transaction, error) {
+	return renex.trader.CancelTx(ctx, renex.client, txHash)
+}
+
+// MigrateLegacyStores copies the orders of a trader that are still open on the
+// network from the randomly named stores created by earlier versions of the
+// SDK into a store, and returns the number of orders copied. Legacy stores are
+// left in place, since they can hold the orders of other traders and networks.
+func MigrateLegacyStores(root string, newClient client.Client, traderAddress common.Address, newStore store.Store) (int, error) {
+	paths, err := leveldb.LegacyStores(root)
+	if err != nil {
+		return 0, err
+	}
+	if len(paths) == 0 {
+		return 0, nil
+	}
+
+	orderbookContract, err := bindings.NewOrderbookCaller(newClient.OrderbookAddress(), newClient.Client())
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, path := range paths {
+		ords, err := leveldb.LegacyOrders(path)
+		if err != nil {
+			return migrated, err
+		}
+		for _, ord := range ords {
+			owner, err := orderbookContract.OrderTrader(&bind.CallOpts{}, ord.ID)
+			if err != nil {
+				return migrated, err
+			}
+			if owner != traderAddress {
+				continue
+			}
+			state, err := orderbookContract.OrderState(&bind.CallOpts{}, ord.ID)
+			if err != nil {
+				return migrated, err
+			}
+			if order.Status(state) != order.Open {
+				continue
+			}
+			if err := newStore.AppendOrder(ord); err != nil {
+				return migrated, err
+			}
+			migrated++
+		}
+	}
+	return migrated, nil
+}
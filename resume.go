@@ -0,0 +1,181 @@
+package renex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/republicprotocol/renex-sdk-go/adapter/client"
+	"github.com/republicprotocol/renex-sdk-go/adapter/journal"
+)
+
+// ErrInterrupted is recorded for operations that were interrupted before
+// their transactions were mined and that cannot be completed automatically.
+var ErrInterrupted = errors.New("operation was interrupted")
+
+// ResumeError is returned by Resume when some operations could not be
+// resumed. Errors holds the error of each of them by operation ID.
+type ResumeError struct {
+	Errors map[string]error
+}
+
+func (err ResumeError) Error() string {
+	ids := make([]string, 0, len(err.Errors))
+	for id := range err.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("%s: %v", id, err.Errors[id])
+	}
+	return fmt.Sprintf("cannot resume %d operations: %s", len(ids), strings.Join(msgs, "; "))
+}
+
+// Resume completes the fund operations that were interrupted by a crash or a
+// restart, and returns them in their final state. Transactions that were
+// broadcast, and their replacements, are waited for. Deposits that sent
+// transactions that were not mined are sent again, unless the nonce of one of
+// them has been used by another transaction. Other operations are marked as
+// failed with ErrInterrupted so that they can be retried by the caller, and
+// canceled operations with a client.TxCanceledError. An operation that cannot
+// be resumed does not stop the others, and a ResumeError is returned with the
+// operations once every operation has been tried.
+func (renex RenEx) Resume(ctx context.Context) ([]journal.Operation, error) {
+	if renex.journal == nil {
+		return []journal.Operation{}, nil
+	}
+	ops, err := renex.journal.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	errs := map[string]error{}
+	for _, op := range ops {
+		if err := renex.resume(ctx, op); err != nil {
+			errs[op.ID] = err
+		}
+	}
+
+	// Read the operations again to return their final state
+	resumed := make([]journal.Operation, 0, len(ops))
+	for _, op := range ops {
+		final, err := renex.journal.Operation(op.ID)
+		if err != nil {
+			errs[op.ID] = err
+			continue
+		}
+		resumed = append(resumed, final)
+	}
+	if len(errs) > 0 {
+		return resumed, ResumeError{Errors: errs}
+	}
+	return resumed, nil
+}
+
+func (renex RenEx) resume(ctx context.Context, op journal.Operation) error {
+	mined := map[string]bool{}
+	nonceUsed := false
+	var canceled error
+	for _, entry := range op.Entries {
+		if entry.State != journal.StateSigned && entry.State != journal.StateSent && entry.State != journal.StateMined {
+			continue
+		}
+		state, used, err := renex.settle(ctx, entry)
+		if err != nil {
+			return err
+		}
+		if state != entry.State {
+			entry.State = state
+			if err := renex.journal.Record(op.ID, entry); err != nil {
+				return err
+			}
+		}
+		if used {
+			nonceUsed = true
+		}
+		if state == journal.StateMined {
+			if entry.Cancel {
+				canceled = client.TxCanceledError{Hash: canceledTx(op, entry), Cancellation: entry.Hash}
+				continue
+			}
+			mined[entry.Step] = true
+		}
+	}
+	if canceled != nil {
+		return renex.journal.Finish(op.ID, canceled)
+	}
+
+	switch op.Kind {
+	case journal.KindDeposit:
+		if mined[journal.StepDeposit] {
+			return renex.journal.Finish(op.ID, nil)
+		}
+		if len(op.Entries) == 0 {
+			// Nothing was recorded, which happens when the deposit was sent
+			// by a trader without the journal, so the deposit may have been
+			// mined and is not sent again
+			break
+		}
+		if nonceUsed {
+			// The nonce of a transaction that was not found has been used
+			// by another transaction, which may have been the deposit, so it
+			// is not sent again
+			break
+		}
+		// A mined approval is detected by the deposit, so only the deposit is
+		// sent again
+		err := renex.Funds.DepositContext(journal.WithStep(ctx, op.ID, string(op.Kind)), op.Token, op.Amount)
+		return renex.journal.Finish(op.ID, err)
+	case journal.KindWithdraw:
+		if mined[journal.StepWithdraw] {
+			return renex.journal.Finish(op.ID, nil)
+		}
+	default:
+		if len(mined) > 0 {
+			return renex.journal.Finish(op.ID, nil)
+		}
+	}
+	return renex.journal.Finish(op.ID, ErrInterrupted)
+}
+
+// settle waits for a transaction recorded in the journal to be confirmed and
+// returns its final state. A transaction that is not found has failed, and
+// settle also reports whether its nonce has been used by another transaction.
+func (renex RenEx) settle(ctx context.Context, entry journal.Entry) (journal.State, bool, error) {
+	tx, _, err := renex.client.Client().TransactionByHash(ctx, entry.Hash)
+	if err == ethereum.NotFound {
+		// The transaction was never broadcast, was dropped by the network or
+		// was replaced by another transaction with the same nonce
+		nonce, err := renex.client.Client().NonceAt(ctx, renex.trader.Address(), nil)
+		if err != nil {
+			return entry.State, false, fmt.Errorf("cannot resume transaction %s: %v", entry.Hash.Hex(), err)
+		}
+		return journal.StateFailed, nonce > entry.Nonce, nil
+	}
+	if err != nil {
+		return entry.State, false, fmt.Errorf("cannot resume transaction %s: %v", entry.Hash.Hex(), err)
+	}
+	if _, err := renex.client.WaitConfirmed(ctx, tx, renex.client.Confirmations()); err != nil {
+		if err == ctx.Err() {
+			return entry.State, false, err
+		}
+		return journal.StateFailed, false, nil
+	}
+	return journal.StateMined, false, nil
+}
+
+// canceledTx returns the transaction that a cancellation replaced, which is
+// the first transaction of its step with the same nonce.
+func canceledTx(op journal.Operation, cancellation journal.Entry) common.Hash {
+	for _, entry := range op.Entries {
+		if entry.Step == cancellation.Step && entry.Nonce == cancellation.Nonce && !entry.Cancel {
+			return entry.Hash
+		}
+	}
+	return common.Hash{}
+}